@@ -0,0 +1,129 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// isMergeable reports whether the allOf member schema can be merged into the object.
+func isMergeable(schema *openapi3.Schema) bool {
+	if isEnum(schema) || isOneOf(schema) || isAnyOf(schema) {
+		return false
+	}
+
+	return schema.Type == "" || schema.Type == openapi3.TypeObject
+}
+
+// mergeAllOf merges the properties, required lists and descriptions of all allOf members of the schema into the single object schema.
+//
+// The schemaPath is the path of the schema to report the conflicting property, such as "#/components/schemas/Pet".
+// mergeAllOf reports false if any member can not be merged, such as enum or primitive.
+func mergeAllOf(schemaPath string, schema *openapi3.Schema) (*openapi3.Schema, bool, error) {
+	merged := &openapi3.Schema{
+		ExtensionProps: schema.ExtensionProps,
+		Type:           openapi3.TypeObject,
+		Title:          schema.Title,
+		Nullable:       schema.Nullable,
+		ReadOnly:       schema.ReadOnly,
+		WriteOnly:      schema.WriteOnly,
+		Deprecated:     schema.Deprecated,
+		Properties:     make(openapi3.Schemas),
+	}
+
+	propPaths := make(map[string]string) // property name to the schema path which defines it
+	var descs []string
+	requireds := make(map[string]bool)
+
+	visited := make(map[*openapi3.Schema]bool)
+
+	var merge func(schemaPath string, schema *openapi3.Schema) (bool, error)
+	merge = func(schemaPath string, schema *openapi3.Schema) (bool, error) {
+		if !isMergeable(schema) {
+			return false, nil
+		}
+		if visited[schema] {
+			return true, nil // recursive allOf, the members are already merged
+		}
+		visited[schema] = true
+		if desc := schema.Description; desc != "" {
+			descs = append(descs, desc)
+		}
+
+		for i, member := range schema.AllOf {
+			if member.Value == nil {
+				continue
+			}
+			memberPath := schemaPath + "/allOf/" + strconv.Itoa(i)
+			if member.Ref != "" {
+				memberPath = member.Ref
+			}
+			ok, err := merge(memberPath, member.Value)
+			if err != nil || !ok {
+				return ok, err
+			}
+		}
+
+		for _, propName := range propertyNames(schema.Properties) {
+			prop := schema.Properties[propName]
+			propPath := schemaPath + "/properties/" + propName
+			if definedPath, ok := propPaths[propName]; ok {
+				if typ, definedTyp := propertyType(prop), propertyType(merged.Properties[propName]); typ != definedTyp {
+					return false, fmt.Errorf("%s: conflicting property type %s with %s in %s", propPath, typ, definedTyp, definedPath)
+				}
+				continue
+			}
+			propPaths[propName] = propPath
+			merged.Properties[propName] = prop
+		}
+		for _, required := range schema.Required {
+			if !requireds[required] {
+				requireds[required] = true
+				merged.Required = append(merged.Required, required)
+			}
+		}
+
+		if merged.AdditionalProperties == nil {
+			merged.AdditionalProperties = schema.AdditionalProperties
+		}
+		if merged.AdditionalPropertiesAllowed == nil {
+			merged.AdditionalPropertiesAllowed = schema.AdditionalPropertiesAllowed
+		}
+
+		return true, nil
+	}
+
+	ok, err := merge(schemaPath, schema)
+	if err != nil || !ok {
+		return nil, ok, err
+	}
+	merged.Description = strings.Join(descs, "\n\n")
+
+	return merged, true, nil
+}
+
+// propertyType returns the type of the property schema to detect the conflict.
+func propertyType(prop *openapi3.SchemaRef) string {
+	if prop.Ref != "" {
+		return prop.Ref
+	}
+
+	schema := prop.Value
+	switch {
+	case schema == nil:
+		return ""
+	case schema.Type == openapi3.TypeArray && schema.Items != nil:
+		return "array<" + propertyType(schema.Items) + ">"
+	case schema.Format != "":
+		return schema.Type + "(" + schema.Format + ")"
+	case schema.Type != "":
+		return schema.Type
+	default:
+		return "object"
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// allOfSpec is the OpenAPI document whose Pet schema is the allOf of the component and inline schemas.
+// The idType is the type of the id property of the inline member.
+func allOfSpec(idType string) string {
+	return `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Base:
+      type: object
+      required: [id]
+      properties:
+        id: {type: string}
+    Named:
+      type: object
+      properties:
+        name: {type: string}
+    Pet:
+      allOf:
+        - $ref: '#/components/schemas/Base'
+        - $ref: '#/components/schemas/Named'
+        - type: object
+          required: [age]
+          properties:
+            age: {type: integer}
+            id: {type: ` + idType + `}
+`
+}
+
+func TestCompileAllOf(t *testing.T) {
+	result := mustCompileSpec(t, allOfSpec("string"))
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.Pet")
+	if msg == nil {
+		t.Fatal("not found Pet message")
+	}
+
+	// the required lists of all members are merged
+	tests := map[string]struct {
+		number   int32
+		optional bool
+	}{
+		"age":  {number: 1},
+		"id":   {number: 2},
+		"name": {number: 3, optional: true},
+	}
+	if got, want := len(msg.GetFields()), len(tests); got != want {
+		t.Fatalf("got %d fields but want %d", got, want)
+	}
+	for fieldName, tt := range tests {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if got := field.GetNumber(); got != tt.number {
+			t.Errorf("%s: got %d field number but want %d", fieldName, got, tt.number)
+		}
+		if got := field.IsProto3Optional(); got != tt.optional {
+			t.Errorf("%s: got %t optional but want %t", fieldName, got, tt.optional)
+		}
+	}
+}
+
+func TestCompileAllOfConflict(t *testing.T) {
+	_, err := compileSpec(t, allOfSpec("integer"))
+	var diags Diagnostics
+	if !errors.As(err, &diags) {
+		t.Fatalf("got %v error but want Diagnostics", err)
+	}
+	if got := diags.ErrorCount(); got != 1 {
+		t.Fatalf("got %d errors but want 1: %v", got, diags)
+	}
+
+	const want = "#/components/schemas/Pet/allOf/2/properties/id: conflicting property type integer with string in #/components/schemas/Base/properties/id"
+	for _, d := range diags {
+		if d.Pointer == "#/components/schemas/Pet" && strings.Contains(d.Message, want) {
+			return
+		}
+	}
+	t.Errorf("not found %q error: %v", want, diags)
+}
+
+func TestCompileComposeAllOf(t *testing.T) {
+	result := mustCompileSpec(t, allOfSpec("integer"), WithComposeAllOf(true))
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.Pet")
+	if msg == nil {
+		t.Fatal("not found Pet message")
+	}
+
+	// the conflicting property is not merged, and each member is the field of the message
+	for fieldName, typeName := range map[string]string{
+		"base":  "test.Base",
+		"named": "test.Named",
+		"pet3":  "test.Pet.Pet3",
+	} {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if got := field.GetMessageType().GetFullyQualifiedName(); got != typeName {
+			t.Errorf("%s: got %s type but want %s", fieldName, got, typeName)
+		}
+	}
+}
@@ -6,18 +6,13 @@ package compiler
 import (
 	"context"
 	"fmt"
-	"os"
-	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/jhump/protoreflect/desc"
-	"github.com/jhump/protoreflect/desc/protoprint"
-	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/types/descriptorpb"
 
 	"go.lsp.dev/openapi2protobuf/openapi"
 	"go.lsp.dev/openapi2protobuf/protobuf"
-	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
 )
 
 var additionalMessages []*protobuf.MessageDescriptorProto
@@ -45,6 +40,12 @@ type option struct {
 	skipDeprecatedRPC  bool
 	usePrefixEnum      bool
 	wrapPrimitives     bool
+	formatTypes        map[string]string
+	fieldNumberLock    *FieldNumberLock
+	composeAllOf       bool
+	successResponses   []string
+	mediaTypes         []string
+	servicePerTag      bool
 	additionalMessages []*protobuf.MessageDescriptorProto
 }
 
@@ -72,6 +73,8 @@ func WithSkipDeprecatedRPC(skipDeprecatedRPC bool) Option {
 }
 
 // WithPrefixEnums prefix enum values with their enum name to prevent protobuf namespacing issues.
+//
+// The enum values are prefixed by default.
 func WithPrefixEnums(usePrefixEnum bool) Option {
 	return func(o *option) { o.usePrefixEnum = usePrefixEnum }
 }
@@ -85,6 +88,57 @@ func WithWrapPrimitives(wrapPrimitives bool) Option {
 	return func(o *option) { o.wrapPrimitives = wrapPrimitives }
 }
 
+// WithFormatTypes sets the Protocol Buffers types of the OpenAPI formats.
+//
+// The key is the OpenAPI format such as "uuid", and the value is the scalar type name such as "string",
+// or the fully-qualified message type name such as "google.type.Decimal".
+// The formatTypes take precedence over the built-in mapping.
+func WithFormatTypes(formatTypes map[string]string) Option {
+	return func(o *option) {
+		if o.formatTypes == nil {
+			o.formatTypes = make(map[string]string)
+		}
+		for format, typ := range formatTypes {
+			o.formatTypes[format] = typ
+		}
+	}
+}
+
+// WithFieldNumberLock sets the FieldNumberLock to keep the field numbers of the previous compile.
+//
+// The updated lock is returned as Result.FieldNumberLock.
+func WithFieldNumberLock(lock *FieldNumberLock) Option {
+	return func(o *option) { o.fieldNumberLock = lock }
+}
+
+// WithComposeAllOf sets whether the compile allOf schema to the message which has the field of each allOf member,
+// instead of the single message which merges all allOf members.
+func WithComposeAllOf(composeAllOf bool) Option {
+	return func(o *option) { o.composeAllOf = composeAllOf }
+}
+
+// WithSuccessResponses sets the rule to select the success response of the operation, which is compiled to the RPC output.
+//
+// The first candidate which the operation has is selected. Each candidate is SuccessLowest, Success2XX, SuccessDefault or the explicit 2xx status code.
+// The default is DefaultSuccessResponses.
+func WithSuccessResponses(rule []string) Option {
+	return func(o *option) { o.successResponses = rule }
+}
+
+// WithMediaTypes sets the media type preference to select the content of the request body and response.
+//
+// The pattern may have the wildcard, such as "application/*+json" or "*/*". The default is DefaultMediaTypes.
+func WithMediaTypes(preference []string) Option {
+	return func(o *option) { o.mediaTypes = preference }
+}
+
+// WithServicePerTag sets whether the compile the operations to the service of their first tag, instead of the single service.
+//
+// The untagged operations are compiled to the default service, and the x-grpc-service-name extension of the tag or operation overrides the service name.
+func WithServicePerTag(servicePerTag bool) Option {
+	return func(o *option) { o.servicePerTag = servicePerTag }
+}
+
 // WithAdditionalMessages adds additional messages.
 func WithAdditionalMessages(additionalMessages []*protobuf.MessageDescriptorProto) Option {
 	return func(o *option) { o.additionalMessages = append(o.additionalMessages, additionalMessages...) }
@@ -97,25 +151,71 @@ type compiler struct {
 	opt        *option
 	components openapi3.Components
 
+	// pinnedNumbers is the field numbers pinned by the x-protobuf-field-number extension
+	pinnedNumbers map[*descriptorpb.FieldDescriptorProto]*pinnedNumber
+
+	// compiling is the component schema names which are being compiled to detect the recursive reference
+	compiling map[string]bool
+
+	// schemaCache is the compiled message of the component schema keyed by its JSON pointer, such as "#/components/schemas/Pet"
+	schemaCache map[string]*protobuf.MessageDescriptorProto
+
+	// incomplete is the component schema names whose compilation cut the recursive reference, which are not cached
+	incomplete map[string]bool
+
+	// pointer is the JSON pointer of the component or operation which is being compiled
+	pointer string
+
+	// diagnostics is the collected warnings and errors
+	diagnostics Diagnostics
+
+	// tags is the tags object keyed by the tag name
+	tags map[string]*openapi3.Tag
+
+	// discriminatorProps is the discriminator property name of the member schemas of the discriminated oneOf
+	discriminatorProps map[*openapi3.Schema]string
+
 	schemasLookupFunc       lookupFunc
 	parametersLookupFunc    lookupFunc
 	requestBodiesLookupFunc lookupFunc
 }
 
 // Compile takes an OpenAPI spec and compiles it into a protobuf file descriptor.
-func Compile(ctx context.Context, spec *openapi.Schema, options ...Option) (*descriptorpb.FileDescriptorProto, error) {
+//
+// Compile does not write anything. Use the returned Result to render the Protocol Buffers source.
+//
+// Compile continues with the next component or operation if one of them can not be compiled,
+// and returns the collected Diagnostics as the error. The warnings of the successful compile are in the Result.
+func Compile(ctx context.Context, spec *openapi.Schema, options ...Option) (*Result, error) {
 	opt := &option{
 		additionalMessages: additionalMessages,
+		successResponses:   DefaultSuccessResponses,
+		mediaTypes:         DefaultMediaTypes,
+		usePrefixEnum:      true,
 	}
 	for _, o := range options {
 		o(opt)
 	}
+	if err := validateSuccessResponses(opt.successResponses); err != nil {
+		return nil, err
+	}
 
 	pkgname := opt.packageName
 	c := &compiler{
-		fdesc:      protobuf.NewFileDescriptorProto(pkgname),
-		opt:        opt,
-		components: spec.Components,
+		fdesc:              protobuf.NewFileDescriptorProto(pkgname),
+		opt:                opt,
+		components:         spec.Components,
+		pinnedNumbers:      make(map[*descriptorpb.FieldDescriptorProto]*pinnedNumber),
+		discriminatorProps: make(map[*openapi3.Schema]string),
+		compiling:          make(map[string]bool),
+		schemaCache:        make(map[string]*protobuf.MessageDescriptorProto),
+		incomplete:         make(map[string]bool),
+		tags:               make(map[string]*openapi3.Tag),
+
+		// the paths are compiled before the components, and also look up the components
+		schemasLookupFunc:       spec.Components.Schemas.JSONLookup,
+		parametersLookupFunc:    spec.Components.Parameters.JSONLookup,
+		requestBodiesLookupFunc: spec.Components.RequestBodies.JSONLookup,
 	}
 
 	// append additional messages
@@ -126,6 +226,8 @@ func Compile(ctx context.Context, spec *openapi.Schema, options ...Option) (*des
 		c.fdesc.AddDependency(deps)
 	}
 
+	c.collectDiscriminators(spec.Components.Schemas)
+
 	// compile info object
 	if err := c.CompileInfo(spec.Info); err != nil {
 		return nil, fmt.Errorf("could not compile info object: %w", err)
@@ -136,6 +238,14 @@ func Compile(ctx context.Context, spec *openapi.Schema, options ...Option) (*des
 		return nil, fmt.Errorf("could not compile servers object: %w", err)
 	}
 
+	// compile tags object before the paths, which are compiled to the service of each tag
+	if err := c.CompileTags(spec.Tags); err != nil {
+		return nil, fmt.Errorf("could not compile tags object: %w", err)
+	}
+
+	// register the component names before the paths, which refer to the component messages
+	c.registerComponents(spec.Components)
+
 	// compile paths object
 	if err := c.CompilePaths(c.opt.packageName, spec.Paths); err != nil {
 		return nil, fmt.Errorf("could not compile paths object: %w", err)
@@ -146,16 +256,16 @@ func Compile(ctx context.Context, spec *openapi.Schema, options ...Option) (*des
 		return nil, fmt.Errorf("could not compile component objects: %w", err)
 	}
 
+	// compile extension objects
+	if err := c.CompileExtensions(spec); err != nil {
+		return nil, fmt.Errorf("could not compile extension objects: %w", err)
+	}
+
 	// compile security object
 	if err := c.CompileSecurity(spec.Security); err != nil {
 		return nil, fmt.Errorf("could not compile security object: %w", err)
 	}
 
-	// compile tags object
-	if err := c.CompileTags(spec.Tags); err != nil {
-		return nil, fmt.Errorf("could not compile tags object: %w", err)
-	}
-
 	// compile external documentation object
 	if err := c.CompileExternalDocs(spec.ExternalDocs); err != nil {
 		return nil, fmt.Errorf("could not compile external documentation object: %w", err)
@@ -163,32 +273,24 @@ func Compile(ctx context.Context, spec *openapi.Schema, options ...Option) (*des
 
 	fd := c.fdesc.Build()
 
-	// add dependency proto
-	depsFileDescriptor := make([]*desc.FileDescriptor, 0, len(dependencyProto))
-	for _, deps := range c.fdesc.GetDependency() {
-		if depDesc, ok := prototype.Descriptor[deps]; ok {
-			knownDesc, err := desc.CreateFileDescriptor(depDesc)
-			if err != nil {
-				return nil, fmt.Errorf("could not create %s descriptor: %w", depDesc.GetName(), err)
-			}
-			depsFileDescriptor = append(depsFileDescriptor, knownDesc)
-			c.fdesc.AddDependency(deps)
-		}
+	// the conflicting field numbers are reported together with the other errors
+	lock := c.applyFieldNumberLock(fd)
+
+	c.locateDiagnostics(spec)
+	if c.diagnostics.HasError() {
+		return nil, c.diagnostics
 	}
 
-	var _ = protojson.Format
-	// fmt.Fprint(os.Stdout, protojson.Format(fd))
-	fdesc, err := desc.CreateFileDescriptor(fd, depsFileDescriptor...)
+	// link dependency proto
+	depsFileDescriptor, err := linkDependencies(c.fdesc.GetDependency())
 	if err != nil {
-		return nil, fmt.Errorf("could not convert to desc: %w", err)
+		return nil, err
 	}
 
-	p := protoprint.Printer{}
-	var sb strings.Builder
-	if err := p.PrintProtoFile(fdesc, &sb); err != nil {
-		return nil, fmt.Errorf("could not print proto: %w", err)
+	fdesc, err := desc.CreateFileDescriptor(fd, depsFileDescriptor...)
+	if err != nil {
+		return nil, fmt.Errorf("could not convert to desc: %w", err)
 	}
-	fmt.Fprint(os.Stdout, sb.String())
 
-	return fd, nil
+	return newResult(fd, fdesc, lock, c.diagnostics)
 }
@@ -0,0 +1,220 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"go.lsp.dev/openapi2protobuf/openapi"
+)
+
+// compileSpec compiles the OpenAPI document of the src to the "test" package.
+func compileSpec(t *testing.T, src string, options ...Option) (*Result, error) {
+	t.Helper()
+
+	filename := filepath.Join(t.TempDir(), "openapi.yaml")
+	if err := os.WriteFile(filename, []byte(src), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	spec, err := openapi.LoadFile(context.Background(), filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return Compile(context.Background(), spec, append([]Option{WithPackageName("test")}, options...)...)
+}
+
+// mustCompileSpec is like compileSpec but fails the test if the src can not be compiled.
+func mustCompileSpec(t *testing.T, src string, options ...Option) *Result {
+	t.Helper()
+
+	result, err := compileSpec(t, src, options...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return result
+}
+
+// validateDescriptorSet validates the descriptor set of the result by protodesc.
+func validateDescriptorSet(t *testing.T, result *Result) {
+	t.Helper()
+
+	b, err := result.Marshal(FormatDescriptorSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	set := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(b, set); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := protodesc.NewFiles(set); err != nil {
+		t.Fatalf("invalid file descriptor set: %v", err)
+	}
+}
+
+func TestCompileDeterministic(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"petstore.yaml", "recursive.yaml"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			spec, err := openapi.LoadFile(ctx, filepath.Join("..", "testdata", "oai", "v3.0", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var want string
+			for i := 0; i < 5; i++ {
+				result, err := Compile(ctx, spec, WithPackageName("test"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if i == 0 {
+					want = result.Source
+					continue
+				}
+				if result.Source != want {
+					t.Fatalf("compile %d: got different source\n%s\nwant\n%s", i, result.Source, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileRecursive(t *testing.T) {
+	ctx := context.Background()
+
+	spec, err := openapi.LoadFile(ctx, filepath.Join("..", "testdata", "oai", "v3.0", "recursive.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Compile(ctx, spec, WithPackageName("recursive"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		field    string
+		typeName string
+		repeated bool
+	}{
+		"TreeNode":                               {field: "parent", typeName: "recursive.TreeNode"},
+		"TreeNode.Children":                      {field: "tree_node", typeName: "recursive.TreeNode", repeated: true},
+		"BinaryExpr":                             {field: "left", typeName: "recursive.Expr"},
+		"Expr":                                   {field: "binary_expr", typeName: "recursive.BinaryExpr"},
+		"JSONValue.ArrayValue":                   {field: "json_value", typeName: "recursive.JSONValue", repeated: true},
+		"JSONValue.ObjectValue.ObjectValueEntry": {field: "value", typeName: "recursive.JSONValue"},
+	}
+	for msgName, tt := range tests {
+		msgName, tt := msgName, tt
+		t.Run(msgName, func(t *testing.T) {
+			msg := result.FileDescriptor.FindMessage("recursive." + msgName)
+			if msg == nil {
+				t.Fatalf("not found %s message", msgName)
+			}
+			field := msg.FindFieldByName(tt.field)
+			if field == nil {
+				t.Fatalf("not found %s field in %s", tt.field, msgName)
+			}
+			if got := field.GetMessageType().GetFullyQualifiedName(); got != tt.typeName {
+				t.Fatalf("%s.%s: got %s type but want %s", msgName, tt.field, got, tt.typeName)
+			}
+			if got := field.IsRepeated(); got != tt.repeated {
+				t.Errorf("%s.%s: got %t repeated but want %t", msgName, tt.field, got, tt.repeated)
+			}
+		})
+	}
+
+	// the recursive reference must not be compiled to the nested copy
+	for _, msgName := range []string{"TreeNode", "BinaryExpr", "Expr"} {
+		msg := result.FileDescriptor.FindMessage("recursive." + msgName)
+		for _, nested := range msg.GetNestedMessageTypes() {
+			if nested.GetName() == msgName || nested.GetName() == "Expr" || nested.GetName() == "BinaryExpr" {
+				t.Errorf("%s has the nested copy of %s", msgName, nested.GetName())
+			}
+		}
+	}
+}
+
+func TestCompileComponentRef(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /pets:
+    get:
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema:
+                oneOf:
+                  - $ref: '#/components/schemas/Dog'
+                  - $ref: '#/components/schemas/Cat'
+components:
+  schemas:
+    Dog:
+      type: object
+      properties:
+        bark: {type: boolean}
+    Cat:
+      type: object
+      properties:
+        meow: {type: boolean}
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	// the component referenced by the paths is compiled by CompileComponents, and is not nested in the response
+	msg := result.FileDescriptor.FindMessage("test.GetPetsResponse")
+	if msg == nil {
+		t.Fatal("not found GetPetsResponse message")
+	}
+	if got := msg.GetNestedMessageTypes(); len(got) != 0 {
+		t.Errorf("got %v nested messages but want none", got)
+	}
+	for fieldName, typeName := range map[string]string{"dog": "test.Dog", "cat": "test.Cat"} {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if got := field.GetMessageType().GetFullyQualifiedName(); got != typeName {
+			t.Errorf("%s: got %s type but want %s", fieldName, got, typeName)
+		}
+	}
+
+	for name := range result.FieldNumberLock.Messages {
+		if name == "GetPetsResponse.Dog" || name == "GetPetsResponse.Cat" {
+			t.Errorf("got the lock of the nested copy %s", name)
+		}
+	}
+}
+
+func BenchmarkCompileProtocol(b *testing.B) {
+	ctx := context.Background()
+
+	spec, err := openapi.LoadFile(ctx, filepath.Join("..", "testdata", "lsp", "3.17", "protocol-3.17.1.openapi.yaml"))
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Compile(ctx, spec, WithPackageName("protocol")); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
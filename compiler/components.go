@@ -7,67 +7,59 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"os"
 	"path"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/reflect/protoreflect"
 	"google.golang.org/protobuf/types/descriptorpb"
 
-	"go.lsp.dev/openapi2protobuf/internal/backtrace"
 	"go.lsp.dev/openapi2protobuf/internal/conv"
 	"go.lsp.dev/openapi2protobuf/protobuf"
+	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
 )
 
-// CompileComponents compiles all component objects.
-func (c *compiler) CompileComponents(components openapi3.Components) error {
-	c.schemasLookupFunc = components.Schemas.JSONLookup
-	c.parametersLookupFunc = components.Parameters.JSONLookup
-	c.requestBodiesLookupFunc = components.RequestBodies.JSONLookup
-
-	schemaNames := make([]string, len(components.Schemas))
-	i := 0
-	for name := range components.Schemas {
-		schemaNames[i] = name
-		i++
-	}
-	sort.Strings(schemaNames)
-	for _, name := range schemaNames {
-		c.fdesc.AddComponent(conv.NormalizeMessageName(name))
+// registerComponents registers the message names of all component objects.
+//
+// The components are registered before the paths are compiled, so the component referenced by an operation is not nested in its messages.
+func (c *compiler) registerComponents(components openapi3.Components) {
+	for _, names := range [][]string{
+		sortedKeys(components.Schemas),
+		sortedKeys(components.Parameters),
+		sortedKeys(components.RequestBodies),
+	} {
+		for _, name := range names {
+			c.fdesc.AddComponent(conv.NormalizeMessageName(name))
+		}
 	}
+}
 
-	parameterNames := make([]string, len(components.Parameters))
-	i = 0
-	for name := range components.Parameters {
-		parameterNames[i] = name
-		i++
-	}
-	sort.Strings(parameterNames)
-	for _, name := range parameterNames {
-		c.fdesc.AddComponent(conv.NormalizeMessageName(name))
+// sortedKeys returns the sorted keys of the m.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
 	}
+	sort.Strings(keys)
 
-	requestBodyNames := make([]string, len(components.RequestBodies))
-	i = 0
-	for name := range components.RequestBodies {
-		requestBodyNames[i] = name
-		i++
-	}
-	sort.Strings(requestBodyNames)
-	for _, name := range requestBodyNames {
-		c.fdesc.AddComponent(conv.NormalizeMessageName(name))
-	}
+	return keys
+}
 
-	for _, name := range schemaNames {
+// CompileComponents compiles all component objects.
+func (c *compiler) CompileComponents(components openapi3.Components) error {
+	for _, name := range sortedKeys(components.Schemas) {
 		schemaRef, ok := components.Schemas[name]
 		if !ok {
 			continue
 		}
 
-		msg, err := c.CompileSchemaRef(name, schemaRef)
+		msg, err := c.compileComponentSchema(name, schemaRef)
 		if err != nil {
 			return err
 		}
@@ -75,43 +67,28 @@ func (c *compiler) CompileComponents(components openapi3.Components) error {
 			continue
 		}
 
-		propertyOrder := msg.GetFieldOrder()
-		propOrder, ok := schemaRef.Value.Extensions["x-propertyOrder"].(json.RawMessage)
-		if ok && propOrder != nil {
-			propertyOrder = propertyOrder[0:] // reset
-			if err := json.Unmarshal(propOrder, &propertyOrder); err != nil {
-				return err
-			}
-		}
-		msg.SortField(propertyOrder)
-
 		c.fdesc.AddMessage(msg)
 	}
 
-	for _, name := range requestBodyNames {
+	for _, name := range sortedKeys(components.RequestBodies) {
 		schemaRef, ok := components.RequestBodies[name]
 		if !ok {
 			continue
 		}
 
+		c.pointer = jsonPointer("components", "requestBodies", name)
 		msg, err := c.CompileRequestBody(name, schemaRef.Value)
+		if err == nil && !skipMessage(msg) {
+			err = sortFields(msg, schemaRef.Value.Extensions)
+		}
 		if err != nil {
-			return err
+			c.addError(c.pointer, fmt.Errorf("%s request body: %w", name, err))
+			continue
 		}
 		if skipMessage(msg) {
 			continue
 		}
 
-		propertyOrder := msg.GetFieldOrder()
-		propOrder, ok := schemaRef.Value.Extensions["x-propertyOrder"].(json.RawMessage)
-		if ok && propOrder != nil {
-			propertyOrder = propertyOrder[0:] // reset
-			if err := json.Unmarshal(propOrder, &propertyOrder); err != nil {
-				return err
-			}
-		}
-		msg.SortField(propertyOrder)
-
 		c.fdesc.AddMessage(msg)
 	}
 
@@ -129,21 +106,82 @@ func (c *compiler) CompileSchemaRef(name string, schemaRef *openapi3.SchemaRef)
 		return nil, errors.New("schemaRef must be non-nil")
 	}
 
-	if val := schemaRef.Value; val != nil && val.AdditionalProperties != nil {
-		additionalProps := val.AdditionalProperties
-		if additionalProps != nil {
-			if additionalProps.Ref == "" {
-				fmt.Fprintf(os.Stderr, "%s\nadditionalProps.Value.Items: %#v\n", backtrace.FuncNameN(1), additionalProps.Value.AnyOf)
-			}
-			return c.CompileSchemaRef("additionalProperties", additionalProps)
+	if component, ok := componentSchemaName(schemaRef.Ref); ok {
+		return c.compileComponentSchema(component, schemaRef)
+	}
+
+	return c.compileSchema(name, schemaRef)
+}
+
+// compileComponentSchema compiles the name component schema once, and returns the cached message on the subsequent calls.
+//
+// The message is named by the component name regardless of the referrer, and its fields are sorted by the "x-propertyOrder" extension.
+// The compile error is added to the diagnostics of the component, and the nil message is cached to skip its references.
+func (c *compiler) compileComponentSchema(name string, schemaRef *openapi3.SchemaRef) (*protobuf.MessageDescriptorProto, error) {
+	pointer := componentSchemaPointer(name)
+	if msg, ok := c.schemaCache[pointer]; ok {
+		return msg, nil
+	}
+
+	if c.compiling[name] {
+		// recursive reference, the message is compiled by the outer compileComponentSchema.
+		// the components on the way are compiled without this reference, so do not cache them
+		for component := range c.compiling {
+			c.incomplete[component] = true
 		}
+		return nil, nil
+	}
+	c.compiling[name] = true
+	outer := c.pointer
+	c.pointer = pointer
+	msg, err := c.compileSchema(name, schemaRef)
+	if err == nil && !skipMessage(msg) {
+		err = sortFields(msg, schemaRef.Value.Extensions)
+	}
+	c.pointer = outer
+	delete(c.compiling, name)
+	if err != nil {
+		c.addError(pointer, err)
+		c.schemaCache[pointer] = nil
+		return nil, nil
+	}
+
+	if c.incomplete[name] {
+		delete(c.incomplete, name)
+		return msg, nil
+	}
+	c.schemaCache[pointer] = msg
+
+	return msg, nil
+}
+
+// sortFields sorts the fields of the msg by the "x-propertyOrder" extension, which lists the property names.
+func sortFields(msg *protobuf.MessageDescriptorProto, extensions map[string]interface{}) error {
+	propOrder, ok := extensions["x-propertyOrder"].(json.RawMessage)
+	if !ok {
+		return nil
+	}
+
+	var propertyOrder []string
+	if err := json.Unmarshal(propOrder, &propertyOrder); err != nil {
+		return fmt.Errorf("unmarshal x-propertyOrder: %w", err)
+	}
+	fieldOrder := make([]string, len(propertyOrder))
+	for i, propName := range propertyOrder {
+		fieldOrder[i] = conv.NormalizeFieldName(propName)
 	}
+	msg.SortField(fieldOrder)
+
+	return nil
+}
 
+// compileSchema compiles the schema of the schemaRef to the name message.
+func (c *compiler) compileSchema(name string, schemaRef *openapi3.SchemaRef) (*protobuf.MessageDescriptorProto, error) {
 	if val := schemaRef.Value; val != nil {
 		// Enum, OneOf, AnyOf, AllOf
 		switch {
 		case isEnum(val):
-			return c.CompileEnum(name, val), nil
+			return c.CompileEnum(name, val)
 
 		case isOneOf(val):
 			return c.CompileOneof(name, val)
@@ -173,12 +211,47 @@ func (c *compiler) CompileSchemaRef(name string, schemaRef *openapi3.SchemaRef)
 
 		case openapi3.TypeObject:
 			return c.CompileObject(name, val)
+
+		case "":
+			if val.AdditionalProperties != nil || val.AdditionalPropertiesAllowed != nil {
+				return c.CompileObject(name, val) // implicit object type
+			}
 		}
 	}
 
 	return nil, nil
 }
 
+// componentSchemasPrefix is the JSON pointer prefix of the component schemas.
+const componentSchemasPrefix = "/components/schemas/"
+
+// componentSchemaName returns the component schema name of the ref, such as "Pet" of "#/components/schemas/Pet".
+func componentSchemaName(ref string) (string, bool) {
+	_, fragment, ok := strings.Cut(ref, "#")
+	if !ok || !strings.HasPrefix(fragment, componentSchemasPrefix) {
+		return "", false
+	}
+
+	return strings.TrimPrefix(fragment, componentSchemasPrefix), true
+}
+
+// isComponentRef reports whether the ref refers to the component schema, whose message is added by CompileComponents.
+func isComponentRef(ref string) bool {
+	_, ok := componentSchemaName(ref)
+	return ok
+}
+
+// componentSchemaPointer returns the JSON pointer of the name component schema, such as "#/components/schemas/Pet".
+func componentSchemaPointer(name string) string {
+	return jsonPointer("components", "schemas", name)
+}
+
+// isRecursiveRef reports whether the ref refers to the component schema which is being compiled.
+func (c *compiler) isRecursiveRef(ref string) bool {
+	component, ok := componentSchemaName(ref)
+	return ok && c.compiling[component]
+}
+
 // isEnum reports whether the schema is enum.
 func isEnum(schema *openapi3.Schema) bool { return schema.Enum != nil }
 
@@ -191,6 +264,80 @@ func isAnyOf(schema *openapi3.Schema) bool { return schema.AnyOf != nil }
 // isAllOf reports whether the schema is allOf.
 func isAllOf(schema *openapi3.Schema) bool { return schema.AllOf != nil }
 
+// isBuiltin reports whether the schema is compiled by CompileBuiltin.
+func isBuiltin(schema *openapi3.Schema) bool {
+	if isEnum(schema) || isOneOf(schema) || isAnyOf(schema) || isAllOf(schema) {
+		return false
+	}
+
+	switch schema.Type {
+	case openapi3.TypeBoolean, openapi3.TypeInteger, openapi3.TypeNumber, openapi3.TypeString:
+		return true
+	default:
+		return false
+	}
+}
+
+// builtinFieldType returns the field type of the primitive schema.
+func builtinFieldType(schema *openapi3.Schema) *descriptorpb.FieldDescriptorProto_Type {
+	switch schema.Type {
+	case openapi3.TypeBoolean:
+		return protobuf.FieldTypeBool()
+	case openapi3.TypeInteger:
+		return IntegerFieldType(schema.Format)
+	case openapi3.TypeNumber:
+		return NumberFieldType(schema.Format)
+	default:
+		return StringFieldType(schema.Format)
+	}
+}
+
+// additionalProperties returns the additionalProperties schema of the object, and reports whether the object allows additional properties.
+//
+// The returned schema is nil if the additional properties are free-form, such as "additionalProperties: true" or the object without any properties.
+func additionalProperties(object *openapi3.Schema) (*openapi3.SchemaRef, bool) {
+	if ap := object.AdditionalProperties; ap != nil {
+		if ap.Ref == "" && (ap.Value == nil || ap.Value.IsEmpty()) {
+			return nil, true // "additionalProperties: {}"
+		}
+		return ap, true
+	}
+	if allowed := object.AdditionalPropertiesAllowed; allowed != nil {
+		return nil, *allowed
+	}
+
+	return nil, len(object.Properties) == 0
+}
+
+// isMap reports whether the schema is the object which has only additional properties.
+func isMap(schema *openapi3.Schema) bool {
+	if isEnum(schema) || isOneOf(schema) || isAnyOf(schema) || isAllOf(schema) {
+		return false
+	}
+	if schema.Type != openapi3.TypeObject && (schema.Type != "" || (schema.AdditionalProperties == nil && schema.AdditionalPropertiesAllowed == nil)) {
+		return false
+	}
+	_, ok := additionalProperties(schema)
+
+	return ok && len(schema.Properties) == 0
+}
+
+// isRequired reports whether the propName property is listed in the required of the object.
+func isRequired(object *openapi3.Schema, propName string) bool {
+	for _, required := range object.Required {
+		if required == propName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isOptional reports whether the propName property of the object is not required or nullable.
+func isOptional(object *openapi3.Schema, propName string, prop *openapi3.Schema) bool {
+	return prop.Nullable || !isRequired(object, propName)
+}
+
 func (c *compiler) CompileBuiltin(name string, schema *openapi3.Schema, fieldType *descriptorpb.FieldDescriptorProto_Type) (*protobuf.MessageDescriptorProto, error) {
 	if fieldType == nil {
 		return nil, errors.New("should fieldType is non-nil")
@@ -200,7 +347,10 @@ func (c *compiler) CompileBuiltin(name string, schema *openapi3.Schema, fieldTyp
 		name = schema.Title
 	}
 	msg := protobuf.NewMessageDescriptorProto(conv.NormalizeMessageName(name))
-	field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(name), fieldType)
+	field, err := c.newBuiltinField(conv.NormalizeFieldName(name), schema, fieldType)
+	if err != nil {
+		return nil, fmt.Errorf("compile %s schema: %w", name, err)
+	}
 	msg.AddField(field)
 	if desc := schema.Description; desc != "" {
 		msg.AddLeadingComment(msg.GetName(), desc)
@@ -209,6 +359,27 @@ func (c *compiler) CompileBuiltin(name string, schema *openapi3.Schema, fieldTyp
 	return msg, nil
 }
 
+// newBuiltinField returns the new field of the primitive schema.
+//
+// The field type is overridden by the x-protobuf-type extension or the schema format, and wrapped if the wrapPrimitives option is enabled.
+// The integer field type is unsigned if the schema minimum is not negative.
+func (c *compiler) newBuiltinField(name string, schema *openapi3.Schema, fieldType *descriptorpb.FieldDescriptorProto_Type) (*protobuf.FieldDescriptorProto, error) {
+	field := protobuf.NewFieldDescriptorProto(name, unsignedFieldType(schema, fieldType))
+
+	typ, ok, err := protobufType(schema)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		typ, ok = c.formatType(schema)
+	}
+	if ok {
+		c.setType(field, typ)
+	}
+
+	return c.wrapPrimitive(field), nil
+}
+
 func (c *compiler) CompileArray(name string, array *openapi3.Schema) (*protobuf.MessageDescriptorProto, error) {
 	if array.Title != "" {
 		name = array.Title
@@ -218,13 +389,16 @@ func (c *compiler) CompileArray(name string, array *openapi3.Schema) (*protobuf.
 	if ref := array.Items.Ref; ref != "" {
 		refBase := path.Base(ref)
 
-		obj := c.components.Schemas[refBase]
-		if obj != nil {
-			refMsg, err := c.CompileObject(refBase, obj.Value)
+		anyItems := false // the items schema is empty, which allows any value
+		if obj := c.components.Schemas[refBase]; obj != nil && !c.isRecursiveRef(ref) {
+			refMsg, err := c.CompileSchemaRef(refBase, array.Items)
 			if err != nil {
 				return nil, fmt.Errorf("compile refObj.Items: %w", err)
 			}
-			if !skipMessage(refMsg) {
+			switch {
+			case skipMessage(refMsg):
+				anyItems = true
+			case obj.Value.Type == openapi3.TypeObject:
 				c.fdesc.AddMessage(refMsg)
 			}
 		}
@@ -247,14 +421,21 @@ func (c *compiler) CompileArray(name string, array *openapi3.Schema) (*protobuf.
 				if err != nil {
 					return nil, fmt.Errorf("compile refObj.Items: %w", err)
 				}
-				c.fdesc.AddMessage(objMsg)
+				if !skipMessage(objMsg) {
+					c.fdesc.AddMessage(objMsg)
+				}
 			}
 
 			typename := refObj.Title
 			if typename == "" {
 				typename = refBase
 			}
+			if anyItems {
+				typename = prototype.Value
+				c.fdesc.AddDependency(prototype.StructProto)
+			}
 			field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(typename), protobuf.FieldTypeMessage())
+			field.SetRepeated()
 			field.SetTypeName(typename)
 			msg.AddField(field)
 			if desc := array.Description; desc != "" {
@@ -262,7 +443,7 @@ func (c *compiler) CompileArray(name string, array *openapi3.Schema) (*protobuf.
 			}
 
 		default:
-			fmt.Fprintf(os.Stderr, "compileArray: refObj: %T: %#v\n", refObj, refObj)
+			c.warnf("%s: unsupported %T items reference %s, skipped", name, refObj, ref)
 		}
 
 		return msg, nil
@@ -280,15 +461,13 @@ func (c *compiler) CompileArray(name string, array *openapi3.Schema) (*protobuf.
 	field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(msg.GetName()), fieldType)
 	field.SetTypeName(itemsMsg.GetName())
 
-	switch protoreflect.EnumNumber(*fieldType) {
-	case protoreflect.EnumNumber(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE):
-		if !c.fdesc.HasComponent(itemsMsg.GetName()) {
-			msg.AddNestedMessage(itemsMsg) // add nested message only MESSAGE type
-		}
-		field.SetTypeName(itemsMsg.GetName())
+	switch typeName := itemsMsg.GetFieldTypeName(); {
+	case isBuiltin(array.Items.Value) && typeName != "":
+		field.SetTypeName(typeName) // message type of the primitive by CompileBuiltin
 
-	default:
-		field.SetTypeName(fieldType.String())
+	case protoreflect.EnumNumber(*fieldType) == protoreflect.EnumNumber(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE):
+		msg.AddNestedMessage(itemsMsg) // add nested message only MESSAGE type
+		field.SetTypeName(itemsMsg.GetName())
 	}
 
 	if desc := array.Items.Value.Description; desc != "" {
@@ -308,84 +487,331 @@ func (c *compiler) CompileObject(name string, object *openapi3.Schema) (*protobu
 	}
 	msg := protobuf.NewMessageDescriptorProto(conv.NormalizeMessageName(name))
 
-	for propName, prop := range object.Properties {
-		if ref := prop.Ref; ref != "" {
-			refBase := path.Base(ref)
-			refObj, err := c.schemasLookupFunc(refBase)
-			if err != nil {
-				return nil, fmt.Errorf("not found %s ref: %w", ref, err)
-			}
+	// the fields are numbered in the property name order, because the properties map has no order
+	for _, propName := range propertyNames(object.Properties) {
+		if _, err := c.compileProperty(msg, object, propName, object.Properties[propName]); err != nil {
+			return nil, err
+		}
+	}
+
+	if valueRef, ok := additionalProperties(object); ok {
+		// the object which has only additional properties is compiled to the message which has the single map field
+		fieldName := conv.NormalizeFieldName(name)
+		if len(object.Properties) > 0 {
+			fieldName = "additional_properties"
+		}
+		field, err := c.newMapField(msg, fieldName, valueRef)
+		if err != nil {
+			return nil, fmt.Errorf("compile additionalProperties: %w", err)
+		}
+		msg.AddField(field)
+		if desc := object.Description; desc != "" {
+			msg.AddLeadingComment(msg.GetName(), desc)
+		}
+	}
+
+	return msg, nil
+}
 
-			switch refObj := refObj.(type) {
-			case *openapi3.Schema:
-				refMsg, err := c.CompileSchemaRef(conv.NormalizeMessageName(refObj.Title), prop)
+// propertyNames returns the sorted property names of the props.
+func propertyNames(props openapi3.Schemas) []string {
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// compileProperty compiles the propName property of the object to the field of the msg, and returns the field.
+//
+// The returned field is nil if the property is skipped.
+func (c *compiler) compileProperty(msg *protobuf.MessageDescriptorProto, object *openapi3.Schema, propName string, prop *openapi3.SchemaRef) (*protobuf.FieldDescriptorProto, error) {
+	if c.isDiscriminatorProperty(object, propName) {
+		return nil, nil // the oneof field of the discriminated oneOf tells the type
+	}
+
+	if ref := prop.Ref; ref != "" {
+		refBase := path.Base(ref)
+		refObj, err := c.schemasLookupFunc(refBase)
+		if err != nil {
+			return nil, fmt.Errorf("not found %s ref: %w", ref, err)
+		}
+
+		switch refObj := refObj.(type) {
+		case *openapi3.Schema:
+			refName := refBase
+			if refObj.Title != "" {
+				refName = refObj.Title
+			}
+			typeName := conv.NormalizeMessageName(refName)
+			if !c.isRecursiveRef(ref) {
+				refMsg, err := c.CompileSchemaRef(typeName, prop)
 				if err != nil {
 					return nil, fmt.Errorf("compile object items: %w", err)
 				}
 				if skipMessage(refMsg) {
-					continue
-				}
-
-				field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(propName), protobuf.FieldTypeMessage())
-				field.SetTypeName(refMsg.GetName())
-				msg.AddField(field)
-				if desc := object.Description; desc != "" {
-					msg.AddLeadingComment(msg.GetName(), desc)
+					return nil, nil
 				}
+				typeName = refMsg.GetName()
+			}
 
-			default:
-				fmt.Fprintf(os.Stderr, "compileObject: refObj: %T: %#v\n", refObj, refObj)
+			field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(propName), protobuf.FieldTypeMessage())
+			field.SetTypeName(typeName)
+			// the sibling extensions of the $ref are ignored, only the x-protobuf-type of the referenced schema is applied
+			typ, ok, err := protobufType(refObj)
+			if err != nil {
+				return nil, fmt.Errorf("compile %s property: %w", propName, err)
 			}
+			if ok {
+				c.setType(field, typ)
+			}
+			c.setFieldBehavior(field, isRequired(object, propName), prop.Value)
+			msg.AddField(field)
+			if desc := object.Description; desc != "" {
+				msg.AddLeadingComment(msg.GetName(), desc)
+			}
+			return field, nil
 
-			continue
+		default:
+			c.warnf("%s: unsupported %T reference %s of %s property, skipped", msg.GetName(), refObj, ref, propName)
+		}
+
+		return nil, nil
+	}
+
+	ext, err := parseFieldExtensions(prop.Value)
+	if err != nil {
+		return nil, fmt.Errorf("compile %s property: %w", propName, err)
+	}
+	fieldName := ext.fieldName(propName)
+	if ext.name != "" {
+		if other, ok := renamedFieldConflict(object, propName, fieldName); ok {
+			// the field of the same name is dropped by AddField, so report it instead of the silent loss
+			c.addError(childPointer(c.pointer, "properties", propName), fmt.Errorf("%s: field name %s of %s property is already used by %s", msg.GetName(), fieldName, propName, other))
+			return nil, nil
 		}
+	}
 
-		propMsg, err := c.CompileSchemaRef(conv.NormalizeMessageName(propName), prop)
+	if isMap(prop.Value) && ext.typ == "" {
+		valueRef, _ := additionalProperties(prop.Value)
+		field, err := c.newMapField(msg, fieldName, valueRef)
 		if err != nil {
-			return nil, fmt.Errorf("compile object items: %w", err)
+			return nil, fmt.Errorf("compile %s map: %w", propName, err)
 		}
-		if skipMessage(propMsg) {
-			continue
+		if desc := prop.Value.Description; desc != "" {
+			field.AddLeadingComment(field.GetName(), desc)
 		}
+		c.applyFieldExtensions(field, prop.Value, ext, childPointer(c.pointer, "properties", propName))
+		c.setFieldBehavior(field, isRequired(object, propName), prop.Value)
+		msg.AddField(field)
+		return field, nil
+	}
 
-		fieldType := propMsg.GetFieldType()
-		field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(propName), fieldType)
-		if prop.Value.Type == openapi3.TypeArray {
-			field.SetRepeated()
-		}
+	propMsg, err := c.CompileSchemaRef(conv.NormalizeMessageName(propName), prop)
+	if err != nil {
+		return nil, fmt.Errorf("compile object items: %w", err)
+	}
+	if skipMessage(propMsg) {
+		return nil, nil
+	}
 
-		switch protoreflect.EnumNumber(*fieldType) {
-		case protoreflect.EnumNumber(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE):
-			if !c.fdesc.HasComponent(propMsg.GetName()) {
-				msg.AddNestedMessage(propMsg) // add nested message only MESSAGE type
-			}
-			msg.AddNestedMessage(propMsg) // add nested message only MESSAGE type
-			field.SetTypeName(propMsg.GetName())
+	fieldType := propMsg.GetFieldType()
+	field := protobuf.NewFieldDescriptorProto(fieldName, fieldType)
+	if prop.Value.Type == openapi3.TypeArray && !isRepeatedMessage(propMsg) {
+		field.SetRepeated()
+	}
 
+	switch typeName := propMsg.GetFieldTypeName(); {
+	case isBuiltin(prop.Value) && typeName != "":
+		field.SetTypeName(typeName) // message type of the primitive by CompileBuiltin
+
+	case protoreflect.EnumNumber(*fieldType) == protoreflect.EnumNumber(descriptorpb.FieldDescriptorProto_TYPE_MESSAGE):
+		msg.AddNestedMessage(propMsg) // add nested message only MESSAGE type
+		field.SetTypeName(propMsg.GetName())
+	}
+
+	if desc := prop.Value.Description; desc != "" {
+		field.AddLeadingComment(field.GetName(), desc)
+	}
+	c.applyFieldExtensions(field, prop.Value, ext, childPointer(c.pointer, "properties", propName))
+	c.setFieldBehavior(field, isRequired(object, propName), prop.Value)
+	// the message type field already has presence
+	if isOptional(object, propName, prop.Value) && prop.Value.Type != openapi3.TypeArray && field.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+		msg.AddProto3OptionalField(field)
+	} else {
+		msg.AddField(field)
+	}
+	if desc := object.Description; desc != "" {
+		msg.AddLeadingComment(msg.GetName(), desc)
+	}
+
+	return field, nil
+}
+
+// newMapField returns the new map<string, T> field of the valueRef schema, and adds the MapEntry nested message to the msg.
+//
+// The field type is google.protobuf.Struct if the valueRef is nil.
+func (c *compiler) newMapField(msg *protobuf.MessageDescriptorProto, name string, valueRef *openapi3.SchemaRef) (*protobuf.FieldDescriptorProto, error) {
+	if valueRef == nil {
+		field := protobuf.NewFieldDescriptorProto(name, protobuf.FieldTypeMessage())
+		field.SetTypeName(prototype.Struct)
+		c.fdesc.AddDependency(prototype.StructProto)
+		return field, nil
+	}
+
+	value, err := c.newMapValueField(msg, name, valueRef)
+	if err != nil {
+		return nil, err
+	}
+
+	entry := protobuf.NewMessageDescriptorProto(mapEntryName(name))
+	entry.SetMapEntry(true)
+	entry.AddField(protobuf.NewFieldDescriptorProto("key", protobuf.FieldTypeString()))
+	entry.AddField(value)
+	msg.AddNestedMessage(entry)
+
+	field := protobuf.NewFieldDescriptorProto(name, protobuf.FieldTypeMessage())
+	field.SetTypeName(entry.GetName())
+	field.SetRepeated()
+
+	return field, nil
+}
+
+// isRepeatedMessage reports whether the msg has the single repeated field, such as the message of the array whose items are referenced.
+//
+// The field of the msg is not repeated again, which would be the array of the arrays.
+func isRepeatedMessage(msg *protobuf.MessageDescriptorProto) bool {
+	fields := msg.Build().GetField()
+	return len(fields) == 1 && fields[0].GetLabel() == descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+}
+
+// mapEntryName returns the implicit map entry message name of the fieldName map field, such as "UrlMapEntry" of "url_map".
+//
+// The name must be the same as protoc, which upper-cases the first letter and each letter after the underscore, and drops the underscores.
+func mapEntryName(fieldName string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range fieldName {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
 		default:
-			field.SetTypeName(fieldType.String())
+			sb.WriteRune(r)
 		}
+	}
+	sb.WriteString("Entry")
 
-		if desc := prop.Value.Description; desc != "" {
-			field.AddLeadingComment(field.GetName(), desc)
-		}
-		msg.AddField(field)
-		if desc := object.Description; desc != "" {
-			msg.AddLeadingComment(msg.GetName(), desc)
+	return sb.String()
+}
+
+// newMapValueField returns the value field of the map entry.
+//
+// The value message is added to the msg as a nested message unless it is a component or primitive.
+func (c *compiler) newMapValueField(msg *protobuf.MessageDescriptorProto, name string, valueRef *openapi3.SchemaRef) (*protobuf.FieldDescriptorProto, error) {
+	value := protobuf.NewFieldDescriptorProto("value", protobuf.FieldTypeMessage())
+
+	if ref := valueRef.Ref; ref != "" && c.isRecursiveRef(ref) {
+		value.SetTypeName(conv.NormalizeMessageName(path.Base(ref))) // compiled by the outer CompileSchemaRef
+		return value, nil
+	}
+
+	valueMsgName := name + "_value"
+	if ref := valueRef.Ref; ref != "" {
+		valueMsgName = path.Base(ref)
+	}
+	valueMsg, err := c.CompileSchemaRef(conv.NormalizeMessageName(valueMsgName), valueRef)
+	if err != nil {
+		return nil, fmt.Errorf("compile map value: %w", err)
+	}
+
+	switch {
+	case skipMessage(valueMsg):
+		value.SetTypeName(prototype.Value)
+		c.fdesc.AddDependency(prototype.StructProto)
+
+	case valueRef.Ref != "":
+		value.SetTypeName(valueMsg.GetName()) // compiled by CompileComponents
+
+	case isBuiltin(valueRef.Value):
+		value.SetType(valueMsg.GetFieldType())
+		if typeName := valueMsg.GetFieldTypeName(); typeName != "" {
+			value.SetTypeName(typeName) // message type of the primitive by CompileBuiltin
 		}
+
+	default:
+		msg.AddNestedMessage(valueMsg)
+		value.SetTypeName(valueMsg.GetName())
 	}
 
-	return msg, nil
+	return value, nil
+}
+
+// setFieldBehavior sets the "google.api.field_behavior" option to the field from the required, readOnly and writeOnly of the prop,
+// and adds the field_behavior.proto to the dependency.
+func (c *compiler) setFieldBehavior(field *protobuf.FieldDescriptorProto, required bool, prop *openapi3.Schema) {
+	var behaviors []annotations.FieldBehavior
+	if required {
+		behaviors = append(behaviors, annotations.FieldBehavior_REQUIRED)
+	}
+	if prop != nil && prop.ReadOnly {
+		behaviors = append(behaviors, annotations.FieldBehavior_OUTPUT_ONLY)
+	}
+	if prop != nil && prop.WriteOnly {
+		behaviors = append(behaviors, annotations.FieldBehavior_INPUT_ONLY)
+	}
+	if len(behaviors) == 0 {
+		return
+	}
+
+	opts := field.GetFieldOption()
+	if opts == nil {
+		opts = &descriptorpb.FieldOptions{}
+		field.SetFieldOption(opts)
+	}
+	proto.SetExtension(opts, annotations.E_FieldBehavior, behaviors)
+	c.fdesc.AddDependency(prototype.FieldBehaviorProto)
 }
 
+// CompileRequestBody compiles the request body object selected by the media type preference.
+//
+// The binary or opaque payload is compiled to the message which has the google.api.HttpBody "body" field.
 func (c *compiler) CompileRequestBody(name string, requestBody *openapi3.RequestBody) (*protobuf.MessageDescriptorProto, error) {
-	content := requestBody.Content["application/json"]
+	mediaType, content, ok := c.selectMediaType(requestBody.Content)
+	if !ok {
+		c.warnf("%s: no content of the preferred media types, skipped", name)
+		return nil, nil
+	}
+
+	if isOpaqueMediaType(mediaType) {
+		msg := protobuf.NewMessageDescriptorProto(conv.NormalizeMessageName(name))
+		field := protobuf.NewFieldDescriptorProto("body", protobuf.FieldTypeMessage())
+		field.SetTypeName(prototype.HttpBody)
+		c.fdesc.AddDependency(prototype.HttpBodyProto)
+		msg.AddField(field)
+		return msg, nil
+	}
+	if content.Schema == nil || content.Schema.Value == nil {
+		return nil, nil
+	}
 
 	return c.CompileObject(name, content.Schema.Value)
 }
 
+// identRe matches the Protocol Buffers identifier.
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
 // CompileEnum compiles enum objects.
-func (c *compiler) CompileEnum(name string, enum *openapi3.Schema) *protobuf.MessageDescriptorProto {
+//
+// The enum values are prefixed with the enum name if the usePrefixEnum option is enabled,
+// or if the value is not the identifier, such as "10" or "1st".
+// CompileEnum returns an error if the value collides with a sibling value, such as "in-progress" and "in_progress".
+// protoc compares the values without the enum name prefix, so the prefix does not resolve the collision.
+func (c *compiler) CompileEnum(name string, enum *openapi3.Schema) (*protobuf.MessageDescriptorProto, error) {
 	if enum.Title != "" {
 		name = enum.Title
 	}
@@ -393,11 +819,26 @@ func (c *compiler) CompileEnum(name string, enum *openapi3.Schema) *protobuf.Mes
 	msg := protobuf.NewMessageDescriptorProto(conv.NormalizeMessageName(name))
 	eb := protobuf.NewEnumDescriptorProto(conv.NormalizeMessageName(name))
 
-	enmuPrefix := conv.NormalizeFieldName(eb.GetName())
+	enmuPrefix := strings.ToUpper(conv.NormalizeFieldName(eb.GetName()))
+
+	// the enum values are scoped to the enclosing message, not the enum itself.
+	// the enclosing message only has this enum, so the siblings are the values of the enum
+	siblings := make(map[string]interface{}) // enumValueKey of the value name to original enum value
+	addValue := func(valueName string, number int32, orig interface{}) error {
+		key := enumValueKey(eb.GetName(), valueName)
+		if other, ok := siblings[key]; ok {
+			return fmt.Errorf("%s schema: enum value %v conflicts with %v as %s in %s scope", name, orig, other, valueName, msg.GetName())
+		}
+		siblings[key] = orig
+		eb.AddValue(protobuf.NewEnumValueDescriptorProto(valueName, number))
+
+		return nil
+	}
 
 	// add _UNSPECIFIED to first enum value
-	unspecified := protobuf.NewEnumValueDescriptorProto(strings.ToUpper(enmuPrefix+"_UNSPECIFIED"), int32(0))
-	eb.AddValue(unspecified)
+	if err := addValue(enmuPrefix+"_UNSPECIFIED", 0, "UNSPECIFIED"); err != nil {
+		return nil, err
+	}
 
 	for i, e := range enum.Enum {
 		var enumValName string
@@ -411,11 +852,17 @@ func (c *compiler) CompileEnum(name string, enum *openapi3.Schema) *protobuf.Mes
 		case float64:
 			enumValName = strconv.FormatFloat(float64(e), 'g', -1, 64)
 		default:
-			fmt.Fprintf(os.Stderr, "%s: enumValName: %T -> %s\n", backtrace.FuncName(), e, e)
+			c.warnf("%s: unsupported enum value %v of %T type, skipped", name, e, e)
+			continue
 		}
 
-		enumVal := protobuf.NewEnumValueDescriptorProto(strings.ToUpper(enmuPrefix+"_"+enumValName), int32(i+1))
-		eb.AddValue(enumVal)
+		enumValName = strings.ToUpper(enumValName)
+		if c.opt.usePrefixEnum || !identRe.MatchString(enumValName) {
+			enumValName = enmuPrefix + "_" + enumValName
+		}
+		if err := addValue(enumValName, int32(i+1), e); err != nil {
+			return nil, err
+		}
 	}
 
 	if desc := enum.Description; desc != "" {
@@ -426,54 +873,141 @@ func (c *compiler) CompileEnum(name string, enum *openapi3.Schema) *protobuf.Mes
 		msg.AddLeadingComment(msg.GetName(), desc)
 	}
 
-	return msg
+	return msg, nil
+}
+
+// enumValueKey returns the key of the valueName which protoc compares to detect the conflicting values in proto3,
+// such as "InProgress" of both "IN_PROGRESS" and "STATUS_IN_PROGRESS" in the Status enum.
+//
+// The key strips the enumName prefix, and ignores the case and underscores of the valueName.
+func enumValueKey(enumName, valueName string) string {
+	prefix := strings.ToLower(strings.ReplaceAll(enumName, "_", ""))
+	trimmed := strings.TrimLeft(valueName, "_")
+	for len(trimmed) > 0 && len(prefix) > 0 && unicode.ToLower(rune(trimmed[0])) == rune(prefix[0]) {
+		trimmed, prefix = strings.TrimLeft(trimmed[1:], "_"), prefix[1:]
+	}
+	if len(prefix) > 0 || len(trimmed) == 0 {
+		trimmed = valueName // no prefix match, or the value name is the prefix itself
+	}
+
+	var sb strings.Builder
+	upper := true
+	for _, r := range trimmed {
+		switch {
+		case r == '_':
+			upper = true
+		case upper:
+			sb.WriteRune(unicode.ToUpper(r))
+			upper = false
+		default:
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+
+	return sb.String()
 }
 
 // CompileOneof compiles oneof objects.
+//
+// If the oneOf has the discriminator, the oneof is named by the discriminator property,
+// and the oneof field of the member ref is named by its discriminator mapping value.
 func (c *compiler) CompileOneof(name string, oneOf *openapi3.Schema) (*protobuf.MessageDescriptorProto, error) {
 	if oneOf.Title != "" {
 		name = oneOf.Title
 	}
 
+	d := oneOf.Discriminator
+	if d != nil && d.PropertyName == "" {
+		d = nil
+	}
+
 	msg := protobuf.NewMessageDescriptorProto(conv.NormalizeMessageName(name))
-	ob := protobuf.NewOneofDescriptorProto(conv.NormalizeFieldName(name))
+	oneofName := conv.NormalizeFieldName(name)
+	if d != nil {
+		oneofName = conv.NormalizeFieldName(d.PropertyName)
+	}
+	ob := protobuf.NewOneofDescriptorProto(oneofName)
 	msg.AddOneof(ob)
 	if desc := oneOf.Description; desc != "" {
 		msg.AddLeadingComment(msg.GetName(), desc)
 	}
 
-	for i, ref := range oneOf.OneOf {
-		nestedMsgName := ref.Value.Title
-		if nestedMsgName == "" {
-			nestedMsgName = name + "_" + strconv.Itoa(i+1)
-		}
-		nestedMsg, err := c.CompileSchemaRef(nestedMsgName, ref)
-		if err != nil {
-			return nil, fmt.Errorf("compile oneof ref: %w", err)
-		}
-		if skipMessage(nestedMsg) {
-			continue
+	fieldNames := make(map[string]string) // field name to the member
+	addField := func(field *protobuf.FieldDescriptorProto, member string) error {
+		if other, ok := fieldNames[field.GetName()]; ok {
+			return fmt.Errorf("%s schema: oneof field %s of %s conflicts with %s", name, field.GetName(), member, other)
 		}
+		fieldNames[field.GetName()] = member
+		msg.AddField(field)
 
-		if nestedMsg.GetName() == "" {
-			nestedMsg.SetName(name + "_" + strconv.Itoa(i+1))
-		}
+		return nil
+	}
 
-		if !c.fdesc.HasComponent(nestedMsg.GetName()) {
-			msg.AddNestedMessage(nestedMsg)
+	for i, ref := range oneOf.OneOf {
+		nestedMsgName := memberName(name, i, ref)
+		typeName := conv.NormalizeMessageName(nestedMsgName)
+		fieldName := conv.NormalizeFieldName(nestedMsgName)
+		member := ref.Ref
+		// the recursive member refers to the component message which is being compiled
+		if !c.isRecursiveRef(ref.Ref) {
+			nestedMsg, err := c.CompileSchemaRef(nestedMsgName, ref)
+			if err != nil {
+				return nil, fmt.Errorf("compile oneof ref: %w", err)
+			}
+			if skipMessage(nestedMsg) {
+				continue
+			}
+
+			if nestedMsg.GetName() == "" {
+				nestedMsg.SetName(name + "_" + strconv.Itoa(i+1))
+			}
+
+			if !isComponentRef(ref.Ref) {
+				msg.AddNestedMessage(nestedMsg)
+			}
+			typeName = nestedMsg.GetName()
+			fieldName = conv.NormalizeFieldName(typeName)
+			if member == "" {
+				member = typeName
+			}
+		}
+		if d != nil && ref.Ref != "" {
+			fieldName = conv.NormalizeFieldName(discriminatorValue(d, ref.Ref))
 		}
-		field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(nestedMsg.GetName()), protobuf.FieldTypeMessage())
+
+		field := protobuf.NewFieldDescriptorProto(fieldName, protobuf.FieldTypeMessage())
 		field.SetOneofIndex(msg.GetOneofIndex())
-		field.SetTypeName(nestedMsg.GetName())
+		field.SetTypeName(typeName)
 		if desc := ref.Value.Description; desc != "" {
 			field.AddLeadingComment(field.GetName(), desc)
 		}
-		msg.AddField(field)
+		if err := addField(field, member); err != nil {
+			return nil, err
+		}
+	}
+
+	// the oneof shares the scope with the fields, such as the text_edit oneof of the TextEdit member
+	if _, ok := fieldNames[oneofName]; ok {
+		ob.SetName(oneofName + "_oneof")
 	}
 
 	return msg, nil
 }
 
+// memberName returns the message name of the i-th oneOf or anyOf member of the name schema.
+//
+// The name is the title of the member, or the component name if the member is a reference.
+func memberName(name string, i int, ref *openapi3.SchemaRef) string {
+	switch {
+	case ref.Value != nil && ref.Value.Title != "":
+		return ref.Value.Title
+	case ref.Ref != "":
+		return path.Base(ref.Ref)
+	default:
+		return name + "_" + strconv.Itoa(i+1)
+	}
+}
+
 // CompileAnyOf compiles anyOf objects.
 func (c *compiler) CompileAnyOf(name string, anyOf *openapi3.Schema) (*protobuf.MessageDescriptorProto, error) {
 	if anyOf.Title != "" {
@@ -481,16 +1015,24 @@ func (c *compiler) CompileAnyOf(name string, anyOf *openapi3.Schema) (*protobuf.
 	}
 
 	msg := protobuf.NewMessageDescriptorProto(conv.NormalizeMessageName(name))
-	ob := protobuf.NewOneofDescriptorProto(conv.NormalizeFieldName(name))
+	oneofName := conv.NormalizeFieldName(name)
+	ob := protobuf.NewOneofDescriptorProto(oneofName)
 	msg.AddOneof(ob)
 	if desc := anyOf.Description; desc != "" {
 		msg.AddLeadingComment(msg.GetName(), desc)
 	}
 
+	fieldNames := make(map[string]bool)
 	for i, ref := range anyOf.AnyOf {
-		anyOfMsgName := ref.Value.Title
-		if anyOfMsgName == "" {
-			anyOfMsgName = name + "_" + strconv.Itoa(i+1)
+		anyOfMsgName := memberName(name, i, ref)
+		if c.isRecursiveRef(ref.Ref) {
+			// the recursive member refers to the component message which is being compiled
+			field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(anyOfMsgName), protobuf.FieldTypeMessage())
+			field.SetOneofIndex(msg.GetOneofIndex())
+			field.SetTypeName(conv.NormalizeMessageName(anyOfMsgName))
+			fieldNames[field.GetName()] = true
+			msg.AddField(field)
+			continue
 		}
 		anyOfMsg, err := c.CompileSchemaRef(anyOfMsgName, ref)
 		if err != nil {
@@ -503,7 +1045,7 @@ func (c *compiler) CompileAnyOf(name string, anyOf *openapi3.Schema) (*protobuf.
 		if anyOfMsg.GetName() == "" {
 			anyOfMsg.SetName(name + "_" + strconv.Itoa(i+1))
 		}
-		if !c.fdesc.HasComponent(anyOfMsg.GetName()) {
+		if !isComponentRef(ref.Ref) {
 			msg.AddNestedMessage(anyOfMsg)
 		}
 
@@ -513,20 +1055,54 @@ func (c *compiler) CompileAnyOf(name string, anyOf *openapi3.Schema) (*protobuf.
 		if desc := ref.Value.Description; desc != "" {
 			field.AddLeadingComment(field.GetName(), desc)
 		}
+		fieldNames[field.GetName()] = true
 		msg.AddField(field)
 	}
 
+	// the oneof shares the scope with the fields, such as the text_edit oneof of the TextEdit member
+	if fieldNames[oneofName] {
+		ob.SetName(oneofName + "_oneof")
+	}
+
 	return msg, nil
 }
 
+// CompileAllOf compiles allOf objects.
+//
+// The allOf members are merged into the single message.
+// If the composeAllOf option is enabled or any member can not be merged, such as enum or primitive,
+// the message has the field of each allOf member instead.
 func (c *compiler) CompileAllOf(name string, allOfs *openapi3.Schema) (*protobuf.MessageDescriptorProto, error) {
+	if !c.opt.composeAllOf {
+		schemaPath := name
+		if c.fdesc.HasComponent(conv.NormalizeMessageName(name)) {
+			schemaPath = componentSchemaPointer(name)
+		}
+		merged, ok, err := mergeAllOf(schemaPath, allOfs)
+		if err != nil {
+			return nil, fmt.Errorf("merge allOf: %w", err)
+		}
+		if ok {
+			if prop, ok := c.discriminatorProps[allOfs]; ok {
+				c.discriminatorProps[merged] = prop
+			}
+			return c.CompileObject(name, merged)
+		}
+	}
+
 	msg := protobuf.NewMessageDescriptorProto(conv.NormalizeMessageName(name))
 	if desc := allOfs.Description; desc != "" {
 		msg.AddLeadingComment(msg.GetName(), desc)
 	}
 
-	for _, allOf := range allOfs.AllOf {
+	for i, allOf := range allOfs.AllOf {
 		allOfMsgName := allOf.Value.Title
+		switch {
+		case allOf.Ref != "":
+			allOfMsgName = path.Base(allOf.Ref) // compiled by CompileComponents
+		case allOfMsgName == "":
+			allOfMsgName = name + "_" + strconv.Itoa(i+1)
+		}
 		allOfMsg, err := c.CompileSchemaRef(allOfMsgName, allOf)
 		if err != nil {
 			return nil, fmt.Errorf("compile allOf ref: %w", err)
@@ -535,7 +1111,7 @@ func (c *compiler) CompileAllOf(name string, allOfs *openapi3.Schema) (*protobuf
 			continue
 		}
 
-		if !c.fdesc.HasComponent(allOfMsg.GetName()) {
+		if !isComponentRef(allOf.Ref) {
 			msg.AddNestedMessage(allOfMsg)
 		}
 
@@ -548,121 +1124,68 @@ func (c *compiler) CompileAllOf(name string, allOfs *openapi3.Schema) (*protobuf
 }
 
 // IntegerFieldType returns the FieldType of the underlying type of integer from the format.
+//
+//	int32, int8, int16    -> int32
+//	int64, long           -> int64
+//	uint32, uint8, uint16 -> uint32
+//	uint64                -> uint64
+//
+// The integer without the format is int32.
 func IntegerFieldType(format string) *descriptorpb.FieldDescriptorProto_Type {
 	switch format {
-	case "int32":
-		return protobuf.FieldTypeInt32()
-
-	case "int64":
+	case "int64", "long":
 		return protobuf.FieldTypeInt64()
 
+	case "uint32", "uint8", "uint16":
+		return protobuf.FieldTypeUint32()
+
+	case "uint64":
+		return protobuf.FieldTypeUint64()
+
 	default:
 		return protobuf.FieldTypeInt32()
 	}
 }
 
 // NumberFieldType returns the FieldType of the underlying type of number from the format.
+//
+//	double         -> double
+//	float          -> float
+//	int32, integer -> int32
+//	int64, long    -> int64
+//	uint32         -> uint32
+//	uint64         -> uint64
+//
+// The number without the format is double to keep the precision.
 func NumberFieldType(format string) *descriptorpb.FieldDescriptorProto_Type {
 	switch format {
-	case "double":
-		return protobuf.FieldTypeDouble()
+	case "float":
+		return protobuf.FieldTypeFloat()
+
+	case "int32", "integer":
+		return protobuf.FieldTypeInt32()
 
 	case "int64", "long":
 		return protobuf.FieldTypeInt64()
 
-	case "integer", "int32":
-		return protobuf.FieldTypeInt64()
+	case "uint32":
+		return protobuf.FieldTypeUint32()
+
+	case "uint64":
+		return protobuf.FieldTypeUint64()
 
 	default:
-		return protobuf.FieldTypeFloat()
+		return protobuf.FieldTypeDouble()
 	}
 }
 
 // StringFieldType returns the FieldType of the underlying type of string from the format.
 func StringFieldType(format string) *descriptorpb.FieldDescriptorProto_Type {
 	switch format {
-	case "byte":
+	case "byte", "binary":
 		return protobuf.FieldTypeBytes()
 
 	default:
 		return protobuf.FieldTypeString()
 	}
 }
-
-func dumpFileDescriptor(fd *descriptorpb.FileDescriptorProto) {
-	var sb strings.Builder
-	sb.WriteString("\n")
-
-	if len(fd.MessageType) > 0 {
-		sb.WriteString("MessageType:\n")
-		for _, msg := range fd.MessageType {
-			sb.WriteString(fmt.Sprintf("%s\n", msg.GetName()))
-			for _, field := range msg.GetField() {
-				typeName := field.GetTypeName()
-				if typeName == "" {
-					typeName = field.GetType().String()
-				}
-				sb.WriteString(fmt.Sprintf("Field: %s -> %s\n", field.GetName(), typeName))
-			}
-			if len(msg.GetEnumType()) > 0 {
-				for _, enum := range msg.EnumType {
-					sb.WriteString(fmt.Sprintf("%s\n", enum.GetName()))
-					for _, value := range enum.GetValue() {
-						sb.WriteString(fmt.Sprintf("Value: %s\n", value))
-					}
-				}
-			}
-			if len(msg.GetNestedType()) > 0 {
-				for _, nested := range msg.GetNestedType() {
-					sb.WriteString(fmt.Sprintf("Field: %s\n", nested.GetName()))
-					for _, field := range nested.GetField() {
-						typeName := field.GetTypeName()
-						if typeName == "" {
-							typeName = field.GetType().String()
-						}
-						sb.WriteString(fmt.Sprintf("Nested: %s -> %s\n", field.GetName(), typeName))
-					}
-				}
-			}
-			sb.WriteString("\n")
-		}
-		sb.WriteString("\n")
-	}
-
-	if len(fd.EnumType) > 0 {
-		sb.WriteString("EnumType:\n")
-		for _, enum := range fd.EnumType {
-			sb.WriteString(fmt.Sprintf("%s\n", enum.GetName()))
-			for _, value := range enum.GetValue() {
-				sb.WriteString(fmt.Sprintf("Value: %s\n", value))
-			}
-		}
-		sb.WriteString("\n")
-	}
-
-	if len(fd.Service) > 0 {
-		sb.WriteString("Service:\n")
-		for _, service := range fd.Service {
-			sb.WriteString(fmt.Sprintf("%#v\n", service.GetName()))
-		}
-		sb.WriteString("\n")
-	}
-
-	if len(fd.Dependency) > 0 {
-		sb.WriteString("Dependency:\n")
-		for _, dep := range fd.Dependency {
-			sb.WriteString(fmt.Sprintf("%#v\n", dep))
-		}
-		sb.WriteString("\n")
-	}
-
-	if len(fd.Extension) > 0 {
-		sb.WriteString("Extension:\n")
-		for _, ext := range fd.Extension {
-			sb.WriteString(fmt.Sprintf("%#v\n", ext.GetName()))
-		}
-		sb.WriteString("\n")
-	}
-
-	fmt.Fprintln(os.Stderr, sb.String())
-}
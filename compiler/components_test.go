@@ -0,0 +1,255 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+
+	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
+)
+
+func TestCompileMapEntryName(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Links:
+      type: object
+      properties:
+        urlMap:
+          type: object
+          additionalProperties: {type: string}
+        httpIDs:
+          type: object
+          additionalProperties: {type: integer}
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.Links")
+	if msg == nil {
+		t.Fatal("not found Links message")
+	}
+	tests := map[string]string{
+		"url_map":  "UrlMapEntry",
+		"http_ids": "HttpIdsEntry",
+	}
+	for fieldName, want := range tests {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if !field.IsMap() {
+			t.Errorf("%s: got %s type but want map", fieldName, field.GetType())
+			continue
+		}
+		if got := field.GetMessageType().GetName(); got != want {
+			t.Errorf("%s: got %s map entry but want %s", fieldName, got, want)
+		}
+	}
+}
+
+func TestCompileEnumPrefix(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Status:
+      type: string
+      enum: [available, sold, 1st]
+    Code:
+      type: integer
+      enum: [10, 20]
+`
+
+	tests := map[string]struct {
+		options []Option
+		want    map[string][]string // enum name to the value names
+	}{
+		"default": {
+			want: map[string][]string{
+				"test.Status.Status": {"STATUS_UNSPECIFIED", "STATUS_AVAILABLE", "STATUS_SOLD", "STATUS_1ST"},
+				"test.Code.Code":     {"CODE_UNSPECIFIED", "CODE_10", "CODE_20"},
+			},
+		},
+		"disabled": {
+			options: []Option{WithPrefixEnums(false)},
+			// the value which is not the identifier is prefixed
+			want: map[string][]string{
+				"test.Status.Status": {"STATUS_UNSPECIFIED", "AVAILABLE", "SOLD", "STATUS_1ST"},
+				"test.Code.Code":     {"CODE_UNSPECIFIED", "CODE_10", "CODE_20"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			result := mustCompileSpec(t, src, tt.options...)
+			validateDescriptorSet(t, result)
+
+			for enumName, want := range tt.want {
+				enum := result.FileDescriptor.FindEnum(enumName)
+				if enum == nil {
+					t.Fatalf("not found %s enum", enumName)
+				}
+				var got []string
+				for _, value := range enum.GetValues() {
+					got = append(got, value.GetName())
+				}
+				if !reflect.DeepEqual(got, want) {
+					t.Errorf("got %v values of %s but want %v", got, enumName, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCompileEnumConflict(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Status:
+      type: string
+      enum: [in-progress, in_progress]
+`
+
+	for _, prefix := range []bool{true, false} {
+		_, err := compileSpec(t, src, WithPrefixEnums(prefix))
+		var diags Diagnostics
+		if !errors.As(err, &diags) {
+			t.Fatalf("prefix %t: got %v error but want Diagnostics", prefix, err)
+		}
+		if want := "enum value in_progress conflicts with in-progress"; !strings.Contains(diags.Error(), want) {
+			t.Errorf("prefix %t: got %q error but want %q", prefix, diags.Error(), want)
+		}
+	}
+}
+
+func TestCompileProto3Optional(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, tag]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+        tag: {type: string, nullable: true}
+        owner:
+          type: object
+          properties:
+            name: {type: string}
+            email: {type: string}
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.Pet")
+	if msg == nil {
+		t.Fatal("not found Pet message")
+	}
+
+	// the message type field already has presence, so it is not proto3 optional
+	tests := map[string]bool{
+		"id":    false,
+		"name":  true,
+		"tag":   true,
+		"owner": false,
+	}
+	for fieldName, optional := range tests {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if got := field.IsProto3Optional(); got != optional {
+			t.Errorf("%s: got %t optional but want %t", fieldName, got, optional)
+		}
+		if !optional {
+			continue
+		}
+
+		oneof := field.GetOneOf()
+		if oneof == nil {
+			t.Errorf("%s: not found the synthetic oneof", fieldName)
+			continue
+		}
+		if got, want := oneof.GetName(), "_"+fieldName; got != want || len(oneof.GetChoices()) != 1 {
+			t.Errorf("%s: got %s oneof of %d fields but want %s of the single field", fieldName, got, len(oneof.GetChoices()), want)
+		}
+	}
+}
+
+func TestCompileFieldBehavior(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    User:
+      type: object
+      required: [id, password]
+      properties:
+        id: {type: string, readOnly: true}
+        password: {type: string, writeOnly: true}
+        createdAt: {type: string, readOnly: true}
+        name: {type: string}
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.User")
+	if msg == nil {
+		t.Fatal("not found User message")
+	}
+	tests := map[string][]annotations.FieldBehavior{
+		"id":         {annotations.FieldBehavior_REQUIRED, annotations.FieldBehavior_OUTPUT_ONLY},
+		"password":   {annotations.FieldBehavior_REQUIRED, annotations.FieldBehavior_INPUT_ONLY},
+		"created_at": {annotations.FieldBehavior_OUTPUT_ONLY},
+		"name":       nil,
+	}
+	for fieldName, want := range tests {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		var got []annotations.FieldBehavior
+		if opts := field.GetFieldOptions(); opts != nil {
+			got, _ = proto.GetExtension(opts, annotations.E_FieldBehavior).([]annotations.FieldBehavior)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v field behavior but want %v", fieldName, got, want)
+		}
+	}
+
+	var found bool
+	for _, dep := range result.FileDescriptorProto.GetDependency() {
+		if dep == prototype.FieldBehaviorProto {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("not found %s dependency: %v", prototype.FieldBehaviorProto, result.FileDescriptorProto.GetDependency())
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"fmt"
+
+	"github.com/jhump/protoreflect/desc"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
+)
+
+// knownDescriptor returns the well-known file descriptor proto of the name.
+func knownDescriptor(name string) (*descriptorpb.FileDescriptorProto, bool) {
+	if fd, ok := prototype.Descriptor[name]; ok {
+		return fd, true
+	}
+	if fd, ok := prototype.KnownCommonDescriptor[name]; ok {
+		return fd, true
+	}
+	if fd, ok := prototype.KnownTypeDescriptor[name]; ok {
+		return fd, true
+	}
+
+	return nil, false
+}
+
+// knownImport returns the well-known proto file which defines the typeName.
+func knownImport(typeName string) (string, bool) {
+	if imp, ok := prototype.Imports[typeName]; ok {
+		return imp, true
+	}
+	if imp, ok := prototype.KnownCommonImports[typeName]; ok {
+		return imp, true
+	}
+	if imp, ok := prototype.KnownTypeImports[typeName]; ok {
+		return imp, true
+	}
+
+	return "", false
+}
+
+// knownEnum reports whether the typeName is the enum type defined in the well-known proto file.
+func knownEnum(typeName string) bool {
+	imp, ok := knownImport(typeName)
+	if !ok {
+		return false
+	}
+	fd, ok := knownDescriptor(imp)
+	if !ok {
+		return false
+	}
+
+	for _, enum := range fd.GetEnumType() {
+		if fd.GetPackage()+"."+enum.GetName() == typeName {
+			return true
+		}
+	}
+
+	return false
+}
+
+// knownExtensionRanges returns the extension ranges of the typeName message defined in the well-known proto file.
+func knownExtensionRanges(typeName string) ([]*descriptorpb.DescriptorProto_ExtensionRange, bool) {
+	imp, ok := knownImport(typeName)
+	if !ok {
+		return nil, false
+	}
+	fd, ok := knownDescriptor(imp)
+	if !ok {
+		return nil, false
+	}
+
+	for _, msg := range fd.GetMessageType() {
+		if fd.GetPackage()+"."+msg.GetName() == typeName {
+			return msg.GetExtensionRange(), true
+		}
+	}
+
+	return nil, false
+}
+
+// inExtensionRanges reports whether the number is in any of the ranges, whose end is exclusive.
+func inExtensionRanges(ranges []*descriptorpb.DescriptorProto_ExtensionRange, number int32) bool {
+	for _, r := range ranges {
+		if number >= r.GetStart() && number < r.GetEnd() {
+			return true
+		}
+	}
+
+	return false
+}
+
+// linkDependencies creates the desc.FileDescriptor of each deps, including their transitive dependencies.
+//
+// The dependency which is not a well-known file descriptor is ignored.
+func linkDependencies(deps []string) ([]*desc.FileDescriptor, error) {
+	seen := make(map[string]*desc.FileDescriptor)
+
+	var link func(name string) (*desc.FileDescriptor, error)
+	link = func(name string) (*desc.FileDescriptor, error) {
+		if fdesc, ok := seen[name]; ok {
+			return fdesc, nil
+		}
+
+		fd, ok := knownDescriptor(name)
+		if !ok {
+			return nil, nil
+		}
+
+		transitive := make([]*desc.FileDescriptor, 0, len(fd.GetDependency()))
+		for _, dep := range fd.GetDependency() {
+			depDesc, err := link(dep)
+			if err != nil {
+				return nil, err
+			}
+			if depDesc == nil {
+				return nil, fmt.Errorf("unknown %s dependency of %s", dep, name)
+			}
+			transitive = append(transitive, depDesc)
+		}
+
+		fdesc, err := desc.CreateFileDescriptor(fd, transitive...)
+		if err != nil {
+			return nil, fmt.Errorf("could not create %s descriptor: %w", name, err)
+		}
+		seen[name] = fdesc
+
+		return fdesc, nil
+	}
+
+	fdescs := make([]*desc.FileDescriptor, 0, len(deps))
+	for _, dep := range deps {
+		fdesc, err := link(dep)
+		if err != nil {
+			return nil, err
+		}
+		if fdesc == nil {
+			continue
+		}
+		fdescs = append(fdescs, fdesc)
+	}
+
+	return fdescs, nil
+}
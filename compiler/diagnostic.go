@@ -0,0 +1,154 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"go.lsp.dev/openapi2protobuf/openapi"
+)
+
+// Severity represents the severity of the Diagnostic.
+type Severity int
+
+const (
+	// SeverityWarning is the unsupported construct which is skipped or compiled approximately.
+	SeverityWarning Severity = iota + 1
+
+	// SeverityError is the construct which can not be compiled.
+	SeverityError
+)
+
+// String returns the string representation of s.
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+}
+
+// Diagnostic represents a warning or error found while compiling the OpenAPI document.
+type Diagnostic struct {
+	// Severity is the severity of the diagnostic.
+	Severity Severity
+
+	// Message is the human-readable description of the diagnostic.
+	Message string
+
+	// Pointer is the JSON pointer into the OpenAPI document, such as "#/components/schemas/Pet".
+	Pointer string
+
+	// Line and Column are the 1-based position of the Pointer in the document, or zero if not available.
+	Line   int
+	Column int
+}
+
+// Format returns the compiler-style "file:line:col: severity: message" representation of d.
+//
+// The Pointer is appended to the filename as the fragment if the position is not available.
+func (d *Diagnostic) Format(filename string) string {
+	pos := filename
+	switch {
+	case d.Line > 0:
+		pos = fmt.Sprintf("%s:%d:%d", filename, d.Line, d.Column)
+	case d.Pointer != "":
+		pos = filename + d.Pointer
+	}
+	if pos == "" {
+		return fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", pos, d.Severity, d.Message)
+}
+
+// String returns the string representation of d without the filename.
+func (d *Diagnostic) String() string {
+	return d.Format("")
+}
+
+// Diagnostics represents a list of Diagnostic.
+//
+// Compile returns the Diagnostics as the error if any of them is SeverityError.
+type Diagnostics []*Diagnostic
+
+// Error returns the diagnostics joined by the newline.
+//
+// Error implements error.
+func (ds Diagnostics) Error() string {
+	ss := make([]string, len(ds))
+	for i, d := range ds {
+		ss[i] = d.String()
+	}
+
+	return strings.Join(ss, "\n")
+}
+
+// HasError reports whether ds has the SeverityError diagnostic.
+func (ds Diagnostics) HasError() bool {
+	return ds.ErrorCount() > 0
+}
+
+// ErrorCount returns the number of the SeverityError diagnostics in ds.
+func (ds Diagnostics) ErrorCount() int {
+	n := 0
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			n++
+		}
+	}
+
+	return n
+}
+
+// warnf adds the SeverityWarning diagnostic at the current pointer.
+func (c *compiler) warnf(format string, args ...interface{}) {
+	c.diagnostics = append(c.diagnostics, &Diagnostic{
+		Severity: SeverityWarning,
+		Message:  fmt.Sprintf(format, args...),
+		Pointer:  c.pointer,
+	})
+}
+
+// addError adds the err as the SeverityError diagnostic at the pointer.
+func (c *compiler) addError(pointer string, err error) {
+	c.diagnostics = append(c.diagnostics, &Diagnostic{
+		Severity: SeverityError,
+		Message:  err.Error(),
+		Pointer:  pointer,
+	})
+}
+
+// locateDiagnostics sets the line and column of the diagnostics from the spec.
+func (c *compiler) locateDiagnostics(spec *openapi.Schema) {
+	for _, d := range c.diagnostics {
+		if line, col, ok := spec.Position(d.Pointer); ok {
+			d.Line, d.Column = line, col
+		}
+	}
+}
+
+// pointerEscaper escapes the JSON pointer token by RFC 6901.
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// jsonPointer returns the JSON pointer of the tokens, such as "#/components/schemas/Pet".
+func jsonPointer(tokens ...string) string {
+	var sb strings.Builder
+	sb.WriteString("#")
+	for _, tok := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(pointerEscaper.Replace(tok))
+	}
+
+	return sb.String()
+}
+
+// childPointer returns the JSON pointer of the tokens under the pointer, such as "#/components/schemas/Pet/properties/name".
+func childPointer(pointer string, tokens ...string) string {
+	return pointer + strings.TrimPrefix(jsonPointer(tokens...), "#")
+}
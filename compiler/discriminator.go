@@ -0,0 +1,74 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"path"
+	"sort"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// collectDiscriminators collects the member schemas of the discriminated oneOf in the schemas,
+// and records the discriminator property name to remove it from the member messages.
+func (c *compiler) collectDiscriminators(schemas openapi3.Schemas) {
+	visited := make(map[*openapi3.Schema]bool)
+
+	var walk func(schemaRef *openapi3.SchemaRef)
+	walk = func(schemaRef *openapi3.SchemaRef) {
+		if schemaRef == nil || schemaRef.Value == nil || visited[schemaRef.Value] {
+			return
+		}
+		schema := schemaRef.Value
+		visited[schema] = true
+
+		if d := schema.Discriminator; d != nil && d.PropertyName != "" {
+			for _, member := range schema.OneOf {
+				if member.Value != nil {
+					c.discriminatorProps[member.Value] = d.PropertyName
+				}
+			}
+		}
+
+		for _, prop := range schema.Properties {
+			walk(prop)
+		}
+		for _, refs := range []openapi3.SchemaRefs{schema.OneOf, schema.AnyOf, schema.AllOf} {
+			for _, ref := range refs {
+				walk(ref)
+			}
+		}
+		walk(schema.Items)
+		walk(schema.AdditionalProperties)
+	}
+
+	for _, schemaRef := range schemas {
+		walk(schemaRef)
+	}
+}
+
+// isDiscriminatorProperty reports whether the propName property of the object is the discriminator of the oneOf which the object is a member of.
+func (c *compiler) isDiscriminatorProperty(object *openapi3.Schema, propName string) bool {
+	prop, ok := c.discriminatorProps[object]
+	return ok && prop == propName
+}
+
+// discriminatorValue returns the discriminator value of the member ref.
+//
+// The value is the mapping key of the ref, or the schema name of the ref if the mapping does not have the ref.
+// The first sorted key is used if the mapping has multiple keys for the ref.
+func discriminatorValue(d *openapi3.Discriminator, ref string) string {
+	values := make([]string, 0, len(d.Mapping))
+	for value, mappingRef := range d.Mapping {
+		if mappingRef == ref || path.Base(mappingRef) == path.Base(ref) {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return path.Base(ref)
+	}
+	sort.Strings(values)
+
+	return values[0]
+}
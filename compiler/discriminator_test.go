@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompileDiscriminatedOneof(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      oneOf:
+        - $ref: '#/components/schemas/Dog'
+        - $ref: '#/components/schemas/Cat'
+      discriminator:
+        propertyName: petType
+        mapping:
+          doggo: '#/components/schemas/Dog'
+    Dog:
+      title: Doggy
+      type: object
+      required: [petType]
+      properties:
+        petType: {type: string}
+        bark: {type: boolean}
+    Cat:
+      type: object
+      properties:
+        petType: {type: string}
+        meow: {type: boolean}
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	pet := result.FileDescriptor.FindMessage("test.Pet")
+	if pet == nil {
+		t.Fatal("not found Pet message")
+	}
+	oneofs := pet.GetOneOfs()
+	if len(oneofs) != 1 || oneofs[0].GetName() != "pet_type" {
+		t.Fatalf("got %v oneofs but want pet_type oneof", oneofs)
+	}
+
+	// the oneof field is named by the mapping value, or the component name if the mapping does not have the member
+	tests := map[string]string{ // field name to the type name
+		"doggo": "test.Doggy",
+		"cat":   "test.Cat",
+	}
+	for fieldName, typeName := range tests {
+		field := pet.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if field.GetOneOf() != oneofs[0] {
+			t.Errorf("%s: got %v oneof but want pet_type", fieldName, field.GetOneOf())
+		}
+		if got := field.GetMessageType().GetFullyQualifiedName(); got != typeName {
+			t.Errorf("%s: got %s type but want %s", fieldName, got, typeName)
+		}
+	}
+	if got := pet.GetNestedMessageTypes(); len(got) != 0 {
+		t.Errorf("got %v nested messages but want none", got)
+	}
+
+	// the discriminator property is removed from the members
+	for msgName, want := range map[string][]string{"test.Doggy": {"bark"}, "test.Cat": {"meow"}} {
+		msg := result.FileDescriptor.FindMessage(msgName)
+		if msg == nil {
+			t.Fatalf("not found %s message", msgName)
+		}
+		var got []string
+		for _, field := range msg.GetFields() {
+			got = append(got, field.GetName())
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v fields but want %v", msgName, got, want)
+		}
+	}
+}
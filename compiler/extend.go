@@ -0,0 +1,176 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.lsp.dev/openapi2protobuf/openapi"
+	"go.lsp.dev/openapi2protobuf/protobuf"
+)
+
+// CompileExtensions compiles the "x-extension" blocks at the root, operation and component schema level
+// to the file level extend declarations.
+//
+// The invalid block is added to the diagnostics, and the other blocks are still compiled.
+func (c *compiler) CompileExtensions(spec *openapi.Schema) error {
+	defined := make(map[string]string) // extendee and field number to the field name
+
+	compile := func(scope, pointer string, extensions map[string]interface{}) {
+		exts, err := parseExtensions(extensions)
+		if err != nil {
+			c.addError(pointer, fmt.Errorf("%s: %w", scope, err))
+			return
+		}
+
+		for i, ext := range exts {
+			if err := c.compileExtension(ext, defined); err != nil {
+				extPointer := pointer
+				if len(exts) > 1 {
+					extPointer += "/" + strconv.Itoa(i)
+				}
+				c.addError(extPointer, fmt.Errorf("%s: %w", scope, err))
+			}
+		}
+	}
+
+	compile("root", jsonPointer(openapi.ExtensionKey), spec.Extensions)
+
+	paths := make([]string, 0, len(spec.Paths))
+	for path := range spec.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		ops := spec.Paths[path].Operations()
+		meths := make([]string, 0, len(ops))
+		for meth := range ops {
+			meths = append(meths, meth)
+		}
+		sort.Strings(meths)
+		for _, meth := range meths {
+			compile(meth+" "+path, jsonPointer("paths", path, strings.ToLower(meth), openapi.ExtensionKey), ops[meth].Extensions)
+		}
+	}
+
+	names := make([]string, 0, len(spec.Components.Schemas))
+	for name := range spec.Components.Schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		schemaRef := spec.Components.Schemas[name]
+		if schemaRef.Value == nil {
+			continue
+		}
+		compile(name+" schema", jsonPointer("components", "schemas", name, openapi.ExtensionKey), schemaRef.Value.Extensions)
+	}
+
+	return nil
+}
+
+// parseExtensions parses the "x-extension" extension, which is the single Extension or list of Extension.
+func parseExtensions(extensions map[string]interface{}) ([]*openapi.Extension, error) {
+	ext, ok := extensions[openapi.ExtensionKey]
+	if !ok {
+		return nil, nil
+	}
+
+	raw, ok := ext.(json.RawMessage)
+	if !ok {
+		return nil, fmt.Errorf("unexpected %s type: %T", openapi.ExtensionKey, ext)
+	}
+
+	var exts []*openapi.Extension
+	if raw = bytes.TrimSpace(raw); len(raw) > 0 && raw[0] == '[' {
+		if err := json.Unmarshal(raw, &exts); err != nil {
+			return nil, fmt.Errorf("unmarshal %s: %w", openapi.ExtensionKey, err)
+		}
+		return exts, nil
+	}
+
+	var single openapi.Extension
+	if err := json.Unmarshal(raw, &single); err != nil {
+		return nil, fmt.Errorf("unmarshal %s: %w", openapi.ExtensionKey, err)
+	}
+
+	return append(exts, &single), nil
+}
+
+// compileExtension compiles the ext to the extension fields of the file.
+func (c *compiler) compileExtension(ext *openapi.Extension, defined map[string]string) error {
+	if ext.Extend == "" {
+		return fmt.Errorf("missing extend of %s", openapi.ExtensionKey)
+	}
+	extendee := c.resolveTypeName(ext.Extend)
+
+	for _, ef := range ext.Fields {
+		switch {
+		case ef.Name == "":
+			return fmt.Errorf("missing name of %s extension field", extendee)
+		case ef.Type == "":
+			return fmt.Errorf("missing type of %s extension field %s", extendee, ef.Name)
+		case !identRe.MatchString(ef.Name):
+			return fmt.Errorf("invalid %s extension field name %q", extendee, ef.Name)
+		}
+		if err := validateFieldNumber(int64(ef.Number)); err != nil {
+			return fmt.Errorf("%s extension field %s: %w", extendee, ef.Name, err)
+		}
+		if ranges, ok := knownExtensionRanges(strings.TrimPrefix(extendee, ".")); ok && !inExtensionRanges(ranges, int32(ef.Number)) {
+			return fmt.Errorf("%s extension field %s: field number %d is not in the extension ranges", extendee, ef.Name, ef.Number)
+		}
+
+		key := fmt.Sprintf("%s:%d", extendee, ef.Number)
+		if other, ok := defined[key]; ok {
+			return fmt.Errorf("%s extension field number %d of %s is already used by %s", extendee, ef.Number, ef.Name, other)
+		}
+		defined[key] = ef.Name
+
+		field := protobuf.NewFieldDescriptorProto(ef.Name, nil)
+		field.SetExtendee(extendee)
+		field.SetFieldNumber(int32(ef.Number))
+		field.SetOptional()
+		c.setExtensionType(field, ef.Type)
+		c.fdesc.AddExtension(field)
+	}
+
+	return nil
+}
+
+// resolveTypeName resolves the typeName to the fully-qualified name if it is well-known, and adds the proto file to the dependency.
+//
+// The typeName is resolved with the "google.protobuf" package if it is not qualified, such as "MethodOptions".
+// Otherwise, the typeName is used as is, which is resolved by the package scope.
+func (c *compiler) resolveTypeName(typeName string) string {
+	typeName = strings.TrimPrefix(typeName, ".")
+
+	for _, name := range []string{typeName, "google.protobuf." + typeName} {
+		if imp, ok := knownImport(name); ok {
+			c.fdesc.AddDependency(imp)
+			return "." + name
+		}
+	}
+
+	return typeName
+}
+
+// setExtensionType sets the extension field type to typ, which is the scalar type name or the message or enum type name.
+func (c *compiler) setExtensionType(field *protobuf.FieldDescriptorProto, typ string) {
+	if _, ok := scalarTypes[typ]; ok {
+		c.setType(field, typ)
+		return
+	}
+
+	typeName := c.resolveTypeName(typ)
+	field.SetType(protobuf.FieldTypeMessage())
+	if knownEnum(strings.TrimPrefix(typeName, ".")) {
+		field.SetType(protobuf.FieldTypeEnum())
+	}
+	field.SetTypeName(typeName)
+}
@@ -0,0 +1,114 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestCompileExtensions(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+x-extension:
+  extend: MessageOptions
+  fields:
+    - {name: resource_type, type: string, number: 50001}
+paths:
+  /pets:
+    get:
+      x-extension:
+        - extend: google.protobuf.MethodOptions
+          fields:
+            - {name: visibility, type: google.protobuf.NullValue, number: 50002}
+      responses:
+        "204": {description: no content}
+components:
+  schemas:
+    Pet:
+      type: object
+      x-extension:
+        extend: FieldOptions
+        fields:
+          - {name: sensitive, type: bool, number: 50003}
+      properties:
+        name: {type: string}
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	tests := map[string]struct {
+		extendee string
+		number   int32
+	}{
+		"resource_type": {extendee: "google.protobuf.MessageOptions", number: 50001},
+		"visibility":    {extendee: "google.protobuf.MethodOptions", number: 50002},
+		"sensitive":     {extendee: "google.protobuf.FieldOptions", number: 50003},
+	}
+	exts := result.FileDescriptor.GetExtensions()
+	if got, want := len(exts), len(tests); got != want {
+		t.Fatalf("got %d extensions but want %d", got, want)
+	}
+	for _, ext := range exts {
+		tt, ok := tests[ext.GetName()]
+		if !ok {
+			t.Errorf("unexpected %s extension", ext.GetName())
+			continue
+		}
+		if got := ext.GetOwner().GetFullyQualifiedName(); got != tt.extendee {
+			t.Errorf("%s: got %s extendee but want %s", ext.GetName(), got, tt.extendee)
+		}
+		if got := ext.GetNumber(); got != tt.number {
+			t.Errorf("%s: got %d field number but want %d", ext.GetName(), got, tt.number)
+		}
+	}
+}
+
+func TestCompileExtensionNumberError(t *testing.T) {
+	tests := map[string]struct {
+		number string
+		want   string
+	}{
+		"overflow": {
+			number: "4294967297",
+			want:   "field number 4294967297 is out of range",
+		},
+		"reserved": {
+			number: "19000",
+			want:   "field number 19000 is in the reserved range",
+		},
+		"not extension range": {
+			number: "5",
+			want:   "field number 5 is not in the extension ranges",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			src := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+x-extension:
+  extend: MessageOptions
+  fields:
+    - {name: resource_type, type: string, number: ` + tt.number + `}
+paths: {}
+`
+			_, err := compileSpec(t, src)
+			var diags Diagnostics
+			if !errors.As(err, &diags) {
+				t.Fatalf("got %v error but want Diagnostics", err)
+			}
+			for _, d := range diags {
+				if d.Pointer == "#/x-extension" && strings.Contains(d.Message, tt.want) {
+					return
+				}
+			}
+			t.Errorf("not found %q error: %v", tt.want, diags)
+		})
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"go.lsp.dev/openapi2protobuf/internal/conv"
+	"go.lsp.dev/openapi2protobuf/protobuf"
+	"go.lsp.dev/openapi2protobuf/protobuf/prototag"
+)
+
+// list of the OpenAPI extensions to control the compiled Protocol Buffers field.
+const (
+	// protobufFieldNumberExtension pins the field number.
+	protobufFieldNumberExtension = "x-protobuf-field-number"
+
+	// protobufNameExtension overrides the field name.
+	protobufNameExtension = "x-protobuf-name"
+
+	// protobufTypeExtension forces the scalar type or the fully-qualified message type of the field.
+	protobufTypeExtension = "x-protobuf-type"
+
+	// protobufJSONNameExtension sets the JSON name of the field.
+	protobufJSONNameExtension = "x-protobuf-json-name"
+)
+
+// fieldExtensions represents the x-protobuf-* extensions of the property schema.
+type fieldExtensions struct {
+	name     string
+	jsonName string
+	number   int32
+	typ      string
+}
+
+// parseFieldExtensions parses the x-protobuf-* extensions of the schema.
+func parseFieldExtensions(schema *openapi3.Schema) (*fieldExtensions, error) {
+	ext := &fieldExtensions{}
+
+	if err := unmarshalExtension(schema.Extensions, protobufNameExtension, &ext.name); err != nil {
+		return nil, err
+	}
+	if ext.name != "" && !identRe.MatchString(ext.name) {
+		return nil, fmt.Errorf("invalid %s extension %q", protobufNameExtension, ext.name)
+	}
+
+	if err := unmarshalExtension(schema.Extensions, protobufJSONNameExtension, &ext.jsonName); err != nil {
+		return nil, err
+	}
+
+	if err := unmarshalExtension(schema.Extensions, protobufFieldNumberExtension, &ext.number); err != nil {
+		return nil, err
+	}
+	if _, ok := schema.Extensions[protobufFieldNumberExtension]; ok {
+		if err := validateFieldNumber(int64(ext.number)); err != nil {
+			return nil, fmt.Errorf("invalid %s extension: %w", protobufFieldNumberExtension, err)
+		}
+	}
+
+	typ, _, err := protobufType(schema)
+	if err != nil {
+		return nil, err
+	}
+	ext.typ = typ
+
+	return ext, nil
+}
+
+// protobufType returns the type name of the x-protobuf-type extension of the schema.
+//
+// The type name is the scalar type name such as "sint64", or the fully-qualified message type name such as "google.type.Money".
+func protobufType(schema *openapi3.Schema) (string, bool, error) {
+	var typ string
+	if err := unmarshalExtension(schema.Extensions, protobufTypeExtension, &typ); err != nil {
+		return "", false, err
+	}
+	if typ == "" {
+		return "", false, nil
+	}
+
+	if _, ok := scalarTypes[typ]; !ok && !strings.Contains(typ, ".") {
+		return "", false, fmt.Errorf("unknown %s extension type %q, must be the scalar type or fully-qualified message type", protobufTypeExtension, typ)
+	}
+
+	return typ, true, nil
+}
+
+// unmarshalExtension unmarshals the name extension into v if exists.
+func unmarshalExtension(extensions map[string]interface{}, name string, v interface{}) error {
+	ext, ok := extensions[name]
+	if !ok {
+		return nil
+	}
+
+	raw, ok := ext.(json.RawMessage)
+	if !ok {
+		return fmt.Errorf("unexpected %s extension type: %T", name, ext)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("unmarshal %s extension: %w", name, err)
+	}
+
+	return nil
+}
+
+// validateFieldNumber reports an error if the number is not allowed for the field.
+//
+// The number is int64 to check the range before it is narrowed to the int32 field number.
+func validateFieldNumber(number int64) error {
+	switch {
+	case number < 1 || number > prototag.MaxNormalTag:
+		return fmt.Errorf("field number %d is out of range 1 to %d", number, prototag.MaxNormalTag)
+	case number >= prototag.SpecialReservedStart && number <= prototag.SpecialReservedEnd:
+		return fmt.Errorf("field number %d is in the reserved range %d to %d", number, prototag.SpecialReservedStart, prototag.SpecialReservedEnd)
+	default:
+		return nil
+	}
+}
+
+// fieldName returns the field name of the propName property, or the x-protobuf-name extension if exists.
+func (ext *fieldExtensions) fieldName(propName string) string {
+	if ext.name != "" {
+		return ext.name
+	}
+
+	return conv.NormalizeFieldName(propName)
+}
+
+// renamedFieldConflict returns the other property of the object whose field name is the fieldName,
+// which the propName property is renamed to by the x-protobuf-name extension.
+func renamedFieldConflict(object *openapi3.Schema, propName, fieldName string) (string, bool) {
+	for _, other := range propertyNames(object.Properties) {
+		if other == propName {
+			continue
+		}
+
+		otherName := conv.NormalizeFieldName(other)
+		if prop := object.Properties[other]; prop.Ref == "" && prop.Value != nil {
+			if ext, err := parseFieldExtensions(prop.Value); err == nil {
+				otherName = ext.fieldName(other)
+			}
+		}
+		if otherName == fieldName {
+			return other, true
+		}
+	}
+
+	return "", false
+}
+
+// applyFieldExtensions applies the x-protobuf-* extensions to the field.
+//
+// The x-protobuf-type extension of the primitive schema is already applied by newBuiltinField.
+// The pinned field number is applied after all messages are compiled, together with the FieldNumberLock.
+//
+// The pointer is the JSON pointer of the property to report the conflict of the pinned field number.
+func (c *compiler) applyFieldExtensions(field *protobuf.FieldDescriptorProto, prop *openapi3.Schema, ext *fieldExtensions, pointer string) {
+	if ext.jsonName != "" {
+		field.SetJsonName(ext.jsonName)
+	}
+	if ext.typ != "" && !isBuiltin(prop) {
+		c.setType(field, ext.typ)
+	}
+	if ext.number != 0 {
+		c.pinnedNumbers[field.Build()] = &pinnedNumber{number: ext.number, pointer: pointer}
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestCompileProtobufTypeExtension(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    OwnerID:
+      type: string
+      x-protobuf-type: int64
+    Pet:
+      type: object
+      properties:
+        ownerId: {$ref: '#/components/schemas/OwnerID'}
+        size:
+          type: integer
+          x-protobuf-type: sfixed64
+        price:
+          type: object
+          properties:
+            units: {type: integer}
+            nanos: {type: integer}
+          x-protobuf-type: google.type.Money
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.Pet")
+	if msg == nil {
+		t.Fatal("not found Pet message")
+	}
+	tests := map[string]struct {
+		typ      descriptorpb.FieldDescriptorProto_Type
+		typeName string
+	}{
+		"owner_id": {typ: descriptorpb.FieldDescriptorProto_TYPE_INT64},
+		"size":     {typ: descriptorpb.FieldDescriptorProto_TYPE_SFIXED64},
+		"price":    {typ: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, typeName: "google.type.Money"},
+	}
+	for fieldName, tt := range tests {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if got := field.GetType(); got != tt.typ {
+			t.Errorf("%s: got %s type but want %s", fieldName, got, tt.typ)
+		}
+		var typeName string
+		if field.GetMessageType() != nil {
+			typeName = field.GetMessageType().GetFullyQualifiedName()
+		}
+		if got := typeName; got != tt.typeName {
+			t.Errorf("%s: got %q type name but want %q", fieldName, got, tt.typeName)
+		}
+	}
+}
+
+func TestCompileFieldExtensions(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name, tag]
+      properties:
+        id:
+          type: integer
+          x-protobuf-field-number: 5
+        name:
+          type: string
+          x-protobuf-name: display_name
+        tag:
+          type: string
+          x-protobuf-json-name: label
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.Pet")
+	if msg == nil {
+		t.Fatal("not found Pet message")
+	}
+	tests := map[string]struct {
+		number   int32
+		jsonName string
+	}{
+		"id":           {number: 5, jsonName: "id"},
+		"display_name": {number: 6, jsonName: "displayName"},
+		"tag":          {number: 7, jsonName: "label"},
+	}
+	for fieldName, tt := range tests {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if got := field.GetNumber(); got != tt.number {
+			t.Errorf("%s: got %d field number but want %d", fieldName, got, tt.number)
+		}
+		if got := field.GetJSONName(); got != tt.jsonName {
+			t.Errorf("%s: got %q JSON name but want %q", fieldName, got, tt.jsonName)
+		}
+	}
+}
+
+func TestCompileFieldNumberError(t *testing.T) {
+	tests := map[string]struct {
+		number  string
+		pointer string
+		want    string
+	}{
+		"duplicated": {
+			number:  "5",
+			pointer: "#/components/schemas/Pet/properties/b",
+			want:    "Pet: field number 5 of b is already used by a",
+		},
+		"reserved range": {
+			number:  "19000",
+			pointer: "#/components/schemas/Pet",
+			want:    "field number 19000 is in the reserved range 19000 to 19999",
+		},
+		"out of range": {
+			number:  "536870912",
+			pointer: "#/components/schemas/Pet",
+			want:    "field number 536870912 is out of range 1 to 536870911",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			src := `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        a:
+          type: string
+          x-protobuf-field-number: 5
+        b:
+          type: string
+          x-protobuf-field-number: ` + tt.number + `
+    Owner:
+      type: object
+      properties:
+        a:
+          type: string
+          x-protobuf-field-number: 19999
+`
+
+			_, err := compileSpec(t, src)
+			var diags Diagnostics
+			if !errors.As(err, &diags) {
+				t.Fatalf("got %v error but want Diagnostics", err)
+			}
+
+			// the error of the Owner is also reported
+			if got := diags.ErrorCount(); got != 2 {
+				t.Fatalf("got %d errors but want 2: %v", got, diags)
+			}
+			var found bool
+			for _, d := range diags {
+				if d.Pointer == tt.pointer && strings.Contains(d.Message, tt.want) {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("not found %q error at %s: %v", tt.want, tt.pointer, diags)
+			}
+		})
+	}
+}
+
+func TestCompileFieldNameConflict(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        a:
+          type: string
+          x-protobuf-name: name
+        name:
+          type: string
+`
+
+	_, err := compileSpec(t, src)
+	var diags Diagnostics
+	if !errors.As(err, &diags) {
+		t.Fatalf("got %v error but want Diagnostics", err)
+	}
+
+	const (
+		pointer = "#/components/schemas/Pet/properties/a"
+		want    = "Pet: field name name of a property is already used by name"
+	)
+	for _, d := range diags {
+		if d.Pointer == pointer && strings.Contains(d.Message, want) {
+			return
+		}
+	}
+	t.Errorf("not found %q error at %s: %v", want, pointer, diags)
+}
@@ -0,0 +1,86 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"github.com/getkin/kin-openapi/openapi3"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"go.lsp.dev/openapi2protobuf/protobuf"
+	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
+)
+
+// knownFormatTypes maps the OpenAPI string format to the well-known message type.
+var knownFormatTypes = map[string]string{
+	"date-time": prototype.Timestamp,
+	"duration":  prototype.Duration,
+	"date":      prototype.Date,
+}
+
+// scalarTypes maps the Protocol Buffers scalar type name to the field type.
+var scalarTypes = map[string]descriptorpb.FieldDescriptorProto_Type{
+	"double":   descriptorpb.FieldDescriptorProto_TYPE_DOUBLE,
+	"float":    descriptorpb.FieldDescriptorProto_TYPE_FLOAT,
+	"int32":    descriptorpb.FieldDescriptorProto_TYPE_INT32,
+	"int64":    descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	"uint32":   descriptorpb.FieldDescriptorProto_TYPE_UINT32,
+	"uint64":   descriptorpb.FieldDescriptorProto_TYPE_UINT64,
+	"sint32":   descriptorpb.FieldDescriptorProto_TYPE_SINT32,
+	"sint64":   descriptorpb.FieldDescriptorProto_TYPE_SINT64,
+	"fixed32":  descriptorpb.FieldDescriptorProto_TYPE_FIXED32,
+	"fixed64":  descriptorpb.FieldDescriptorProto_TYPE_FIXED64,
+	"sfixed32": descriptorpb.FieldDescriptorProto_TYPE_SFIXED32,
+	"sfixed64": descriptorpb.FieldDescriptorProto_TYPE_SFIXED64,
+	"bool":     descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+	"string":   descriptorpb.FieldDescriptorProto_TYPE_STRING,
+	"bytes":    descriptorpb.FieldDescriptorProto_TYPE_BYTES,
+}
+
+// formatType returns the type name of the schema format.
+//
+// The format types given by WithFormatTypes take precedence over the well-known format types of the string.
+func (c *compiler) formatType(schema *openapi3.Schema) (string, bool) {
+	if typ, ok := c.opt.formatTypes[schema.Format]; ok {
+		return typ, true
+	}
+
+	if schema.Type == openapi3.TypeString {
+		typ, ok := knownFormatTypes[schema.Format]
+		return typ, ok
+	}
+
+	return "", false
+}
+
+// setType sets the field type to typ, which is the scalar type name or fully-qualified message type name,
+// and adds the proto file which defines the message type to the dependency if it is well-known.
+func (c *compiler) setType(field *protobuf.FieldDescriptorProto, typ string) {
+	if scalar, ok := scalarTypes[typ]; ok {
+		field.SetType(scalar.Enum())
+		field.ClearTypeName() // the scalar field may be the message field of the $ref
+		return
+	}
+
+	field.SetType(protobuf.FieldTypeMessage())
+	field.SetTypeName(typ)
+	if imp, ok := knownImport(typ); ok {
+		c.fdesc.AddDependency(imp)
+	}
+}
+
+// unsignedFieldType returns the unsigned type of the integer fieldType if the schema minimum is not negative.
+func unsignedFieldType(schema *openapi3.Schema, fieldType *descriptorpb.FieldDescriptorProto_Type) *descriptorpb.FieldDescriptorProto_Type {
+	if schema.Min == nil || *schema.Min < 0 {
+		return fieldType
+	}
+
+	switch *fieldType {
+	case descriptorpb.FieldDescriptorProto_TYPE_INT32:
+		return protobuf.FieldTypeUint32()
+	case descriptorpb.FieldDescriptorProto_TYPE_INT64:
+		return protobuf.FieldTypeUint64()
+	default:
+		return fieldType
+	}
+}
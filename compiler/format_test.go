@@ -0,0 +1,124 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// fieldType is the type and type name of the compiled field.
+type fieldType struct {
+	typ      descriptorpb.FieldDescriptorProto_Type
+	typeName string
+}
+
+// testFieldTypes compiles the src and tests the field types of the Value message.
+func testFieldTypes(t *testing.T, src string, tests map[string]fieldType, options ...Option) {
+	t.Helper()
+
+	result := mustCompileSpec(t, src, options...)
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.Value")
+	if msg == nil {
+		t.Fatal("not found Value message")
+	}
+	for fieldName, tt := range tests {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if got := field.GetType(); got != tt.typ {
+			t.Errorf("%s: got %s type but want %s", fieldName, got, tt.typ)
+		}
+		var typeName string
+		if field.GetMessageType() != nil {
+			typeName = field.GetMessageType().GetFullyQualifiedName()
+		}
+		if typeName != tt.typeName {
+			t.Errorf("%s: got %q type name but want %q", fieldName, typeName, tt.typeName)
+		}
+	}
+}
+
+func TestCompileStringFormats(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Value:
+      type: object
+      required: [createdAt, ttl, birthday, data, encoded, id, plain]
+      properties:
+        createdAt: {type: string, format: date-time}
+        ttl: {type: string, format: duration}
+        birthday: {type: string, format: date}
+        data: {type: string, format: binary}
+        encoded: {type: string, format: byte}
+        id: {type: string, format: uuid}
+        plain: {type: string}
+`
+
+	testFieldTypes(t, src, map[string]fieldType{
+		"created_at": {typ: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, typeName: "google.protobuf.Timestamp"},
+		"ttl":        {typ: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, typeName: "google.protobuf.Duration"},
+		"birthday":   {typ: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, typeName: "google.type.Date"},
+		"data":       {typ: descriptorpb.FieldDescriptorProto_TYPE_BYTES},
+		"encoded":    {typ: descriptorpb.FieldDescriptorProto_TYPE_BYTES},
+		"id":         {typ: descriptorpb.FieldDescriptorProto_TYPE_STRING},
+		"plain":      {typ: descriptorpb.FieldDescriptorProto_TYPE_STRING},
+	})
+
+	// the format types option overrides the well-known format types
+	testFieldTypes(t, src, map[string]fieldType{
+		"created_at": {typ: descriptorpb.FieldDescriptorProto_TYPE_STRING},
+		"id":         {typ: descriptorpb.FieldDescriptorProto_TYPE_BYTES},
+		"birthday":   {typ: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, typeName: "google.type.Date"},
+	}, WithFormatTypes(map[string]string{"date-time": "string", "uuid": "bytes"}))
+}
+
+func TestCompileNumberFormats(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Value:
+      type: object
+      required: [count, total, small, big, size, length, price, ratio, amount, delta, flags, id]
+      properties:
+        count: {type: integer}
+        total: {type: integer, format: int64}
+        small: {type: integer, format: uint32}
+        big: {type: integer, format: uint64}
+        size: {type: integer, minimum: 0}
+        length: {type: integer, format: int64, minimum: 0}
+        price: {type: number}
+        ratio: {type: number, format: float}
+        amount: {type: number, format: int64}
+        delta: {type: integer, format: int64, x-protobuf-type: sint64}
+        flags: {type: integer, x-protobuf-type: fixed32}
+        id: {type: integer, format: int64, x-protobuf-type: sfixed64}
+`
+
+	testFieldTypes(t, src, map[string]fieldType{
+		"count":  {typ: descriptorpb.FieldDescriptorProto_TYPE_INT32},
+		"total":  {typ: descriptorpb.FieldDescriptorProto_TYPE_INT64},
+		"small":  {typ: descriptorpb.FieldDescriptorProto_TYPE_UINT32},
+		"big":    {typ: descriptorpb.FieldDescriptorProto_TYPE_UINT64},
+		"size":   {typ: descriptorpb.FieldDescriptorProto_TYPE_UINT32},
+		"length": {typ: descriptorpb.FieldDescriptorProto_TYPE_UINT64},
+		"price":  {typ: descriptorpb.FieldDescriptorProto_TYPE_DOUBLE},
+		"ratio":  {typ: descriptorpb.FieldDescriptorProto_TYPE_FLOAT},
+		"amount": {typ: descriptorpb.FieldDescriptorProto_TYPE_INT64},
+		"delta":  {typ: descriptorpb.FieldDescriptorProto_TYPE_SINT64},
+		"flags":  {typ: descriptorpb.FieldDescriptorProto_TYPE_FIXED32},
+		"id":     {typ: descriptorpb.FieldDescriptorProto_TYPE_SFIXED64},
+	})
+}
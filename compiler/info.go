@@ -10,12 +10,14 @@ import (
 )
 
 // CompileInfo compiles info object.
+//
+// The package name is derived from the title only if the package name option is not given.
 func (c *compiler) CompileInfo(info *openapi3.Info) error {
 	if info == nil {
 		return nil
 	}
 
-	if title := info.Title; title != "" {
+	if title := info.Title; title != "" && c.opt.packageName == "" {
 		c.fdesc.SetPackage(conv.NormalizeFieldName(title))
 	}
 
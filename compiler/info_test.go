@@ -0,0 +1,44 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"testing"
+)
+
+func TestCompileInfo(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: Swagger Petstore, version: "1"}
+paths: {}
+`
+
+	tests := map[string]struct {
+		packageName string
+		wantPackage string
+		wantName    string
+	}{
+		"package name": {
+			packageName: "foo.v1",
+			wantPackage: "foo.v1",
+			wantName:    "foo_v1.proto",
+		},
+		"title": {
+			wantPackage: "swagger_petstore",
+			wantName:    "swagger_petstore.proto",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			result := mustCompileSpec(t, src, WithPackageName(tt.packageName))
+			if got := result.FileDescriptorProto.GetPackage(); got != tt.wantPackage {
+				t.Errorf("got %q package but want %q", got, tt.wantPackage)
+			}
+			if got := result.FileDescriptorProto.GetName(); got != tt.wantName {
+				t.Errorf("got %q file name but want %q", got, tt.wantName)
+			}
+		})
+	}
+}
@@ -0,0 +1,256 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sort"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"go.lsp.dev/openapi2protobuf/protobuf/prototag"
+)
+
+// FieldNumberLock represents the field numbers of the compiled messages to keep them stable across regenerations.
+type FieldNumberLock struct {
+	// Messages is the lock of each message keyed by the message name.
+	// The nested message name is joined with the parent message name by a dot, such as "Pet.Owner".
+	Messages map[string]*MessageLock `json:"messages"`
+}
+
+// MessageLock represents the field numbers and reserved fields of the message.
+type MessageLock struct {
+	// Fields maps the field name to the field number.
+	Fields map[string]int32 `json:"fields"`
+
+	// ReservedNumbers is the field numbers of the removed fields.
+	ReservedNumbers []int32 `json:"reserved_numbers,omitempty"`
+
+	// ReservedNames is the field names of the removed fields.
+	ReservedNames []string `json:"reserved_names,omitempty"`
+}
+
+// NewFieldNumberLock returns the new empty FieldNumberLock.
+func NewFieldNumberLock() *FieldNumberLock {
+	return &FieldNumberLock{
+		Messages: make(map[string]*MessageLock),
+	}
+}
+
+// LoadFieldNumberLock loads the FieldNumberLock from the filename.
+//
+// LoadFieldNumberLock returns the empty FieldNumberLock if the filename does not exist.
+func LoadFieldNumberLock(filename string) (*FieldNumberLock, error) {
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return NewFieldNumberLock(), nil
+		}
+		return nil, fmt.Errorf("could not read %s lock file: %w", filename, err)
+	}
+
+	lock := NewFieldNumberLock()
+	if err := json.Unmarshal(b, lock); err != nil {
+		return nil, fmt.Errorf("could not unmarshal %s lock file: %w", filename, err)
+	}
+	if lock.Messages == nil {
+		lock.Messages = make(map[string]*MessageLock)
+	}
+
+	return lock, nil
+}
+
+// Marshal returns the JSON encoding of l.
+func (l *FieldNumberLock) Marshal() ([]byte, error) {
+	b, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal field number lock: %w", err)
+	}
+
+	return append(b, '\n'), nil
+}
+
+// pinnedNumber represents the field number pinned by the x-protobuf-field-number extension.
+type pinnedNumber struct {
+	number int32
+
+	// pointer is the JSON pointer of the property which pins the number
+	pointer string
+}
+
+// applyFieldNumberLock renumbers the fields of all messages in the fd by the field number lock option, and returns the updated lock.
+//
+// The pinned field has the pinned number. The field in the lock keeps its number,
+// and the new field gets the fresh number which is greater than any number used before.
+// The field in the lock but not in the message is marked as reserved.
+//
+// The message which can not be renumbered is reported as the error diagnostic, and keeps its lock.
+func (c *compiler) applyFieldNumberLock(fd *descriptorpb.FileDescriptorProto) *FieldNumberLock {
+	lock := c.opt.fieldNumberLock
+	if lock == nil {
+		lock = NewFieldNumberLock()
+	}
+
+	updated := NewFieldNumberLock()
+	for name, msgLock := range lock.Messages {
+		updated.Messages[name] = msgLock // keep the lock of removed messages
+	}
+
+	var apply func(prefix string, msgs []*descriptorpb.DescriptorProto)
+	apply = func(prefix string, msgs []*descriptorpb.DescriptorProto) {
+		for _, msg := range msgs {
+			name := prefix + msg.GetName()
+			apply(name+".", msg.GetNestedType())
+			if msg.GetOptions().GetMapEntry() {
+				continue // the map entry fields are always numbered 1 and 2
+			}
+
+			msgLock, pointer, err := lockMessage(msg, lock.Messages[name], c.pinnedNumbers)
+			if err != nil {
+				c.addError(pointer, fmt.Errorf("%s: %w", name, err))
+				continue
+			}
+			updated.Messages[name] = msgLock
+		}
+	}
+	apply("", fd.GetMessageType())
+
+	return updated
+}
+
+// lockMessage renumbers the fields of the msg by the locked message and pinned numbers, and returns the updated lock of the msg.
+//
+// lockMessage returns an error if the pinned number is duplicated, or conflicts with the locked or reserved number of another field,
+// together with the JSON pointer of the pinning property.
+func lockMessage(msg *descriptorpb.DescriptorProto, locked *MessageLock, pinned map[*descriptorpb.FieldDescriptorProto]*pinnedNumber) (*MessageLock, string, error) {
+	if locked == nil {
+		locked = &MessageLock{}
+	}
+
+	var maxNumber int32
+	use := func(number int32) {
+		if number > maxNumber {
+			maxNumber = number
+		}
+	}
+
+	pinnedFields := make(map[int32]*descriptorpb.FieldDescriptorProto) // pinned number to the field
+	for _, field := range msg.GetField() {
+		pin, ok := pinned[field]
+		if !ok {
+			continue
+		}
+		if other, ok := pinnedFields[pin.number]; ok {
+			return nil, pin.pointer, fmt.Errorf("field number %d of %s is already used by %s", pin.number, field.GetName(), other.GetName())
+		}
+		pinnedFields[pin.number] = field
+		use(pin.number)
+	}
+	for _, name := range lockedFieldNames(locked) {
+		number := locked.Fields[name]
+		if field, ok := pinnedFields[number]; ok && field.GetName() != name {
+			return nil, pinned[field].pointer, fmt.Errorf("field number %d of %s is already used by %s in the lock", number, field.GetName(), name)
+		}
+		use(number)
+	}
+	for _, number := range locked.ReservedNumbers {
+		if field, ok := pinnedFields[number]; ok {
+			return nil, pinned[field].pointer, fmt.Errorf("field number %d of %s is reserved", number, field.GetName())
+		}
+		use(number)
+	}
+
+	msgLock := &MessageLock{
+		Fields:          make(map[string]int32, len(msg.GetField())),
+		ReservedNumbers: append([]int32(nil), locked.ReservedNumbers...),
+		ReservedNames:   append([]string(nil), locked.ReservedNames...),
+	}
+
+	for _, field := range msg.GetField() {
+		var number int32
+		pin, ok := pinned[field]
+		if ok {
+			number = pin.number
+		} else {
+			number, ok = locked.Fields[field.GetName()]
+		}
+		if !ok {
+			number = nextFieldNumber(maxNumber)
+			if number > prototag.MaxNormalTag {
+				return nil, "", fmt.Errorf("field number of %s exceeds the maximum %d", field.GetName(), prototag.MaxNormalTag)
+			}
+			use(number)
+		}
+		field.Number = proto.Int32(number)
+		msgLock.Fields[field.GetName()] = number
+	}
+
+	// reserve the removed fields
+	for name, number := range locked.Fields {
+		if _, ok := msgLock.Fields[name]; ok {
+			continue
+		}
+		msgLock.ReservedNumbers = append(msgLock.ReservedNumbers, number)
+		msgLock.ReservedNames = append(msgLock.ReservedNames, name)
+	}
+	sort.Slice(msgLock.ReservedNumbers, func(i, j int) bool { return msgLock.ReservedNumbers[i] < msgLock.ReservedNumbers[j] })
+
+	// the re-added field can not use the reserved name, but its old number is still reserved
+	reservedNames := msgLock.ReservedNames[:0]
+	for _, name := range msgLock.ReservedNames {
+		if _, ok := msgLock.Fields[name]; !ok {
+			reservedNames = append(reservedNames, name)
+		}
+	}
+	msgLock.ReservedNames = reservedNames
+	sort.Strings(msgLock.ReservedNames)
+
+	msg.ReservedRange = reservedRanges(msgLock.ReservedNumbers)
+	msg.ReservedName = msgLock.ReservedNames
+
+	return msgLock, "", nil
+}
+
+// lockedFieldNames returns the sorted field names of the locked message to report the same conflict on every compile.
+func lockedFieldNames(locked *MessageLock) []string {
+	names := make([]string, 0, len(locked.Fields))
+	for name := range locked.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// nextFieldNumber returns the next field number of the number, which skips the implementation reserved range.
+func nextFieldNumber(number int32) int32 {
+	number++
+	if number >= prototag.SpecialReservedStart && number <= prototag.SpecialReservedEnd {
+		number = prototag.SpecialReservedEnd + 1
+	}
+
+	return number
+}
+
+// reservedRanges returns the reserved ranges of the sorted numbers, which merges the consecutive numbers.
+func reservedRanges(numbers []int32) []*descriptorpb.DescriptorProto_ReservedRange {
+	var ranges []*descriptorpb.DescriptorProto_ReservedRange
+	for _, number := range numbers {
+		if n := len(ranges); n > 0 && ranges[n-1].GetEnd() == number {
+			ranges[n-1].End = proto.Int32(number + 1) // the end is exclusive
+			continue
+		}
+		ranges = append(ranges, &descriptorpb.DescriptorProto_ReservedRange{
+			Start: proto.Int32(number),
+			End:   proto.Int32(number + 1),
+		})
+	}
+
+	return ranges
+}
@@ -0,0 +1,87 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFieldNumberLock(t *testing.T) {
+	const v1 = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id, name, tag]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+        tag: {type: string}
+`
+	// the tag property is removed, and the age property is added before the others in the name order
+	const v2 = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths: {}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [age, id, name]
+      properties:
+        age: {type: integer}
+        id: {type: integer}
+        name: {type: string}
+`
+
+	result := mustCompileSpec(t, v1)
+	filename := filepath.Join(t.TempDir(), "lock.json")
+	b, err := result.FieldNumberLock.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filename, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	lock, err := LoadFieldNumberLock(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[string]int32{"id": 1, "name": 2, "tag": 3}; !reflect.DeepEqual(lock.Messages["Pet"].Fields, want) {
+		t.Fatalf("got %v lock but want %v", lock.Messages["Pet"].Fields, want)
+	}
+
+	result = mustCompileSpec(t, v2, WithFieldNumberLock(lock))
+	msg := result.FileDescriptor.FindMessage("test.Pet")
+	if msg == nil {
+		t.Fatal("not found Pet message")
+	}
+	for name, want := range map[string]int32{"id": 1, "name": 2, "age": 4} {
+		if got := msg.FindFieldByName(name).GetNumber(); got != want {
+			t.Errorf("%s: got %d field number but want %d", name, got, want)
+		}
+	}
+
+	pb := msg.AsDescriptorProto()
+	if got, want := pb.GetReservedName(), []string{"tag"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v reserved names but want %v", got, want)
+	}
+	if got := pb.GetReservedRange(); len(got) != 1 || got[0].GetStart() != 3 || got[0].GetEnd() != 4 {
+		t.Errorf("got %v reserved ranges but want 3 to 4", got)
+	}
+
+	updated := result.FieldNumberLock.Messages["Pet"]
+	if want := map[string]int32{"id": 1, "name": 2, "age": 4}; !reflect.DeepEqual(updated.Fields, want) {
+		t.Errorf("got %v updated lock but want %v", updated.Fields, want)
+	}
+	if want := []int32{3}; !reflect.DeepEqual(updated.ReservedNumbers, want) {
+		t.Errorf("got %v updated reserved numbers but want %v", updated.ReservedNumbers, want)
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// DefaultMediaTypes is the default media type preference of the request and response content.
+var DefaultMediaTypes = []string{
+	"application/json",
+	"application/*+json",
+	"application/x-www-form-urlencoded",
+	"multipart/form-data",
+	"application/octet-stream",
+	"*/*",
+}
+
+// selectMediaType returns the media type and its content which matches the first pattern of the media type preference.
+//
+// The pattern may have the wildcard, such as "application/*+json" or "*/*".
+// The media type parameters, such as "; charset=utf-8", are ignored.
+func (c *compiler) selectMediaType(content openapi3.Content) (string, *openapi3.MediaType, bool) {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	for _, pattern := range c.opt.mediaTypes {
+		for _, mediaType := range mediaTypes {
+			if ok, _ := path.Match(pattern, baseMediaType(mediaType)); ok {
+				return baseMediaType(mediaType), content[mediaType], true
+			}
+		}
+	}
+
+	return "", nil, false
+}
+
+// baseMediaType returns the lower-cased mediaType without the parameters.
+func baseMediaType(mediaType string) string {
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+
+	return strings.ToLower(strings.TrimSpace(mediaType))
+}
+
+// isJSONMediaType reports whether the mediaType is the JSON, such as "application/json" or "application/merge-patch+json".
+func isJSONMediaType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// isFormMediaType reports whether the mediaType is the form, whose schema properties are the form fields or multipart parts.
+func isFormMediaType(mediaType string) bool {
+	return mediaType == "application/x-www-form-urlencoded" || strings.HasPrefix(mediaType, "multipart/")
+}
+
+// isOpaqueMediaType reports whether the mediaType is the binary or opaque payload, which is compiled to google.api.HttpBody.
+func isOpaqueMediaType(mediaType string) bool {
+	return !isJSONMediaType(mediaType) && !isFormMediaType(mediaType)
+}
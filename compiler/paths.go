@@ -14,17 +14,32 @@ import (
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 
 	"go.lsp.dev/openapi2protobuf/internal/conv"
 	"go.lsp.dev/openapi2protobuf/protobuf"
+	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
 )
 
-var queryRe = regexp.MustCompile(`/{(\w+)}`)
+var (
+	queryRe     = regexp.MustCompile(`/{(\w+)}`)
+	pathParamRe = regexp.MustCompile(`{([^}]+)}`)
+)
 
 // CompilePaths compiles paths object.
+//
+// CompilePaths compiles nothing if the skipRPC option is enabled, because the request and response messages are only referenced by the RPC methods.
 func (c *compiler) CompilePaths(serviceName string, paths openapi3.Paths) error {
-	svc := protobuf.NewServiceDescriptorProto(conv.NormalizeMessageName(serviceName) + "Service")
+	if c.opt.skipRPC {
+		return nil
+	}
+
+	defaultService := conv.NormalizeMessageName(serviceName) + "Service"
+	services := map[string]*protobuf.ServiceDescriptorProto{
+		defaultService: protobuf.NewServiceDescriptorProto(defaultService),
+	}
 
 	sorted := make([]string, len(paths))
 	i := 0
@@ -78,277 +93,471 @@ func (c *compiler) CompilePaths(serviceName string, paths openapi3.Paths) error
 			if op == nil {
 				continue
 			}
-
-			// prepend the http method name to the RPC method name
-			methName := conv.NormalizeMessageName(meth) + name
-			if grpcMethodName, ok := op.Extensions["x-grpc-method-name"]; ok {
-				if err := json.Unmarshal(grpcMethodName.(json.RawMessage), &methName); err != nil {
-					return fmt.Errorf("unmarshal x-grpc-method-name extension: %w", err)
-				}
+			// the request and response messages are also dropped because only the op references them
+			if op.Deprecated && c.opt.skipDeprecatedRPC {
+				continue
 			}
 
-			inputMsgName := methName + "Request"
-			outputMsgName := methName + "Response"
-
-			method := protobuf.NewMethodDescriptorProto(methName, inputMsgName, outputMsgName)
-
-			var fieldOrder []string // for keep parameters order
-			inputMsg := protobuf.NewMessageDescriptorProto(inputMsgName)
-			// first, check whether the op has parameters and defines proto message fields
-			if params := op.Parameters; len(params) > 0 {
-				for _, param := range params {
-					var pname string
-					var paramVal *openapi3.Parameter
-
-					switch {
-					case param.Ref != "":
-						pname = pathpkg.Base(param.Ref)
-						paramVal = c.components.Parameters[pname].Value
-					case param.Value != nil:
-						pname = param.Value.Name
-						paramVal = param.Value
-					}
-
-					p, ok := c.components.Parameters[pname]
-					if !ok {
-						continue
-					}
-
-					var fieldType *descriptorpb.FieldDescriptorProto_Type
-					pv := p.Value.Schema.Value
-					switch pv.Type {
-					case openapi3.TypeBoolean:
-						fieldType = protobuf.FieldTypeBool()
-
-					case openapi3.TypeInteger:
-						fieldType = IntegerFieldType(pv.Format)
-
-					case openapi3.TypeNumber:
-						fieldType = NumberFieldType(pv.Format)
-
-					case openapi3.TypeString:
-						fieldType = StringFieldType(pv.Format)
-					}
-
-					fieldName := conv.NormalizeFieldName(pname)
-					// trim parameter in type name from field name
-					fieldName = strings.ReplaceAll(fieldName, "_"+conv.NormalizeFieldName(paramVal.In), "")
-
-					field := protobuf.NewFieldDescriptorProto(fieldName, fieldType)
-					if desc := paramVal.Description; desc != "" {
-						field.AddLeadingComment(field.GetName(), desc)
-					}
-
-					fieldOrder = append(fieldOrder, field.GetName())
-					inputMsg.AddField(field)
-				}
+			c.pointer = jsonPointer("paths", path, strings.ToLower(meth))
+			svc, err := c.operationService(services, defaultService, op)
+			if err == nil {
+				err = c.compileOperation(svc, path, name, meth, op)
+			}
+			if err != nil {
+				c.addError(c.pointer, fmt.Errorf("%s %s operation: %w", meth, path, err))
 			}
+		}
+	}
 
-			// parse RequestBody for inputMsg
-			if rb := op.RequestBody; rb != nil {
-				var reqBody *openapi3.RequestBody
-				switch {
-				case rb.Ref != "":
-					reqBody = c.components.RequestBodies[pathpkg.Base(rb.Ref)].Value
-				case rb.Value != nil:
-					reqBody = rb.Value
-				}
+	// the services are added in the name order, which is the order of the built file descriptor
+	names := make([]string, 0, len(services))
+	for name, svc := range services {
+		// the default service is empty if all operations are compiled to the service of their tag
+		if name == defaultService && len(services) > 1 && len(svc.Build().GetMethod()) == 0 {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c.fdesc.AddService(services[name])
+	}
 
-				content, ok := reqBody.Content["application/json"]
-				if !ok {
-					continue
-				}
+	return nil
+}
 
-				var fieldVal *openapi3.Schema
-				switch {
-				case content.Schema.Ref != "":
-					fieldVal = c.components.Schemas[pathpkg.Base(content.Schema.Ref)].Value
-				case content.Schema.Value != nil:
-					fieldVal = content.Schema.Value
-				}
+// compileOperation compiles the meth operation of the path to the RPC method of the svc, and its request and response messages.
+//
+// The name is the UpperCamelCase name of the path, which is used for the RPC method name.
+func (c *compiler) compileOperation(svc *protobuf.ServiceDescriptorProto, path, name, meth string, op *openapi3.Operation) error {
+	// prepend the http method name to the RPC method name
+	methName := conv.NormalizeMessageName(meth) + name
+	if grpcMethodName, ok := op.Extensions["x-grpc-method-name"]; ok {
+		if err := json.Unmarshal(grpcMethodName.(json.RawMessage), &methName); err != nil {
+			return fmt.Errorf("unmarshal x-grpc-method-name extension: %w", err)
+		}
+	}
 
-				fieldName := fieldVal.Title
-				fieldType := protobuf.FieldTypeMessage()
+	inputMsgName := methName + "Request"
+	outputMsgName := methName + "Response"
 
-				field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(fieldName), fieldType)
-				field.SetTypeName(fieldName)
-				if desc := fieldVal.Description; desc != "" {
-					field.AddLeadingComment(field.GetName(), desc)
-				}
+	var fieldOrder []string               // for keep parameters order
+	pathFields := make(map[string]string) // path parameter name to field name
+	// the request body is compiled first, because the fields of the inline object body are added to the request message
+	inputMsg, bodyField, err := c.compileRequestBody(inputMsgName, methName, op.RequestBody, c.hasNonPathParameter(op))
+	if err != nil {
+		return err
+	}
 
-				fieldOrder = append(fieldOrder, field.GetName())
-				inputMsg.AddField(field)
+	// first, check whether the op has parameters and defines proto message fields.
+	// the pointer is kept at the parameter if it can not be compiled, to report the returned error at the parameter
+	opPointer := c.pointer
+	for i, param := range op.Parameters {
+		c.pointer = childPointer(opPointer, "parameters", strconv.Itoa(i))
+
+		// the inline parameter is compiled directly, and the ref parameter is resolved from the component parameters
+		var pname string
+		paramVal := param.Value
+		switch ref := param.Ref; ref {
+		case "":
+			if paramVal == nil {
+				continue
 			}
-			inputMsg.SortField(fieldOrder)
-			c.fdesc.AddMessage(inputMsg)
-
-			// parse Responses for outputMsg
-			outputMsg := protobuf.NewMessageDescriptorProto(outputMsgName)
-			for status, resp := range op.Responses {
-				st, _ := strconv.ParseInt(status, 10, 64)
-				// TODO(zchee): handle other than 200(http.StatusOK) status
-				switch st {
-				case http.StatusOK:
-					var val *openapi3.Schema
-
-					v := c.components.Schemas[pathpkg.Base(resp.Ref)]
-					if v != nil {
-						switch {
-						case v.Ref != "":
-							val = c.components.Schemas[pathpkg.Base(v.Ref)].Value
-						case v.Value != nil:
-							val = v.Value
-						}
-					}
-
-					if val == nil {
-						var content *openapi3.Response
-						switch {
-						case resp.Ref != "":
-							content = c.components.Responses[pathpkg.Base(resp.Ref)].Value
-						case resp.Value != nil:
-							content = resp.Value
-						}
-
-						switch {
-						case content.Content != nil:
-							switch {
-							case content.Content["application/json"].Schema.Ref != "":
-								val = c.components.Schemas[pathpkg.Base(content.Content["application/json"].Schema.Ref)].Value
-							case content.Content["application/json"].Schema.Value != nil:
-								val = content.Content["application/json"].Schema.Value
-							}
-						default:
-							continue
-						}
-					}
-
-					var fieldType *descriptorpb.FieldDescriptorProto_Type
-					switch val.Type {
-					case openapi3.TypeBoolean:
-						fieldType = protobuf.FieldTypeBool()
-
-					case openapi3.TypeInteger:
-						fieldType = IntegerFieldType(val.Format)
-
-					case openapi3.TypeNumber:
-						fieldType = NumberFieldType(val.Format)
-
-					case openapi3.TypeString:
-						fieldType = StringFieldType(val.Format)
-
-					case openapi3.TypeArray:
-						fieldType = protobuf.FieldTypeMessage()
-
-					case openapi3.TypeObject:
-						fieldType = protobuf.FieldTypeMessage()
-					}
-					field := protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(val.Title), fieldType)
-					if val.Type == openapi3.TypeObject {
-						field.SetTypeName(val.Title)
-					}
-
-					switch {
-					case val.Description != "":
-						if decs := val.Description; decs != "" {
-							field.AddLeadingComment(field.GetName(), decs)
-						}
-					case val.Title != "":
-						if title := val.Title; title != "" {
-							field.AddLeadingComment(field.GetName(), title)
-						}
-					}
-					outputMsg.AddField(field)
-
-					allOfs := val.AllOf
-					if allOfs != nil {
-						for _, allOf := range allOfs {
-							var valsOrder []string // for keep allOf field order
-							vals := make(map[string]*openapi3.Schema)
-
-							// parse properties
-							if properties := allOf.Value.Properties; properties != nil {
-								for name, prop := range properties {
-									val := prop.Value
-									if val == nil {
-										val = c.components.Schemas[pathpkg.Base(prop.Ref)].Value
-										name = val.Title
-									}
-									valsOrder = append(valsOrder, name)
-									vals[name] = val
-								}
-							}
-							sort.Strings(valsOrder)
-
-							// parse allOf
-							if allOfRef := allOf.Ref; allOfRef != "" {
-								name := pathpkg.Base(allOfRef)
-								val := c.components.Schemas[name].Value
-
-								valsOrder = append(valsOrder, name)
-								vals[name] = val
-							}
-
-							for _, name := range valsOrder {
-								val := vals[name]
-
-								var field *protobuf.FieldDescriptorProto
-								switch val.Type {
-								case openapi3.TypeBoolean:
-									field = protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(name), protobuf.FieldTypeBool())
-									if title := val.Title; title != "" {
-										field.AddLeadingComment(field.GetName(), title)
-									}
-
-								case openapi3.TypeInteger:
-									field = protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(name), IntegerFieldType(val.Format))
-									if title := val.Title; title != "" {
-										field.AddLeadingComment(field.GetName(), title)
-									}
-
-								case openapi3.TypeNumber:
-									field = protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(name), NumberFieldType(val.Format))
-									if title := val.Title; title != "" {
-										field.AddLeadingComment(field.GetName(), title)
-									}
-
-								case openapi3.TypeString:
-									field = protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(name), StringFieldType(val.Format))
-									if title := val.Title; title != "" {
-										field.AddLeadingComment(field.GetName(), title)
-									}
-
-								case openapi3.TypeArray:
-									field = protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(name), protobuf.FieldTypeMessage())
-									if desc := val.Description; desc != "" {
-										field.AddLeadingComment(field.GetName(), desc)
-									}
-
-								case openapi3.TypeObject:
-									field = protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(name), protobuf.FieldTypeMessage())
-									field.SetTypeName(name)
-									if desc := val.Description; desc != "" {
-										field.AddLeadingComment(field.GetName(), desc)
-									}
-								}
-
-								outputMsg.AddField(field)
-							}
-						}
-					}
-
-					if description := val.Title; description != "" {
-						outputMsg.AddLeadingComment(outputMsg.GetName(), description)
-					}
-				}
+			pname = paramVal.Name
+		default:
+			pname = pathpkg.Base(ref)
+			p, ok := c.components.Parameters[pname]
+			if !ok || p.Value == nil {
+				c.warnf("unresolvable %s parameter, skipped", ref)
+				continue
 			}
-			c.fdesc.AddMessage(outputMsg)
+			paramVal = p.Value
+		}
+
+		if paramVal.Schema == nil || paramVal.Schema.Value == nil {
+			c.warnf("%s parameter: the parameter without schema is not supported, skipped", pname)
+			continue
+		}
+		pv := paramVal.Schema.Value
 
-			svc.AddMethod(method)
+		fieldName := conv.NormalizeFieldName(pname)
+		// trim parameter in type name from field name
+		fieldName = strings.ReplaceAll(fieldName, "_"+conv.NormalizeFieldName(paramVal.In), "")
+
+		if paramVal.In == openapi3.ParameterInPath {
+			pathFields[paramVal.Name] = fieldName
 		}
+
+		if !isBuiltin(pv) {
+			fields, err := c.compileParameterSchema(inputMsg, fieldName, paramVal)
+			if err != nil {
+				return fmt.Errorf("compile %s parameter: %w", pname, err)
+			}
+			fieldOrder = append(fieldOrder, fields...)
+			continue
+		}
+
+		field, err := c.newBuiltinField(fieldName, pv, builtinFieldType(pv))
+		if err != nil {
+			return fmt.Errorf("compile %s parameter: %w", pname, err)
+		}
+		if desc := paramVal.Description; desc != "" {
+			field.AddLeadingComment(field.GetName(), desc)
+		}
+
+		fieldOrder = append(fieldOrder, field.GetName())
+		inputMsg.AddField(field)
+	}
+	c.pointer = opPointer
+
+	if bodyField != "" && bodyField != "*" {
+		fieldOrder = append(fieldOrder, bodyField)
+	}
+	inputMsg.SortField(fieldOrder)
+	c.fdesc.AddMessage(inputMsg)
+
+	// parse Responses for outputMsg
+	outputType, err := c.compileSuccessResponse(outputMsgName, op)
+	if err != nil {
+		return err
+	}
+	errorLines, err := c.compileErrorResponses(methName, op)
+	if err != nil {
+		return err
+	}
+
+	method := protobuf.NewMethodDescriptorProto(methName, inputMsgName, outputType)
+	if len(errorLines) > 0 {
+		method.SetLeadingComment(" " + strings.Join(errorLines, "\n "))
+	}
+
+	if c.opt.useAnnotation {
+		if op.RequestBody != nil && bodyField == "" {
+			bodyField = "*"
+		}
+		opts := &descriptorpb.MethodOptions{}
+		proto.SetExtension(opts, annotations.E_Http, httpRule(meth, path, pathFields, bodyField))
+		method.SetMethodOptions(opts)
+		c.fdesc.AddDependency(prototype.AnnotationsProto)
 	}
 
-	c.fdesc.AddService(svc)
+	svc.AddMethod(method)
 
 	return nil
 }
+
+// compileParameterSchema compiles the array, object or enum schema of the param to the fields of the msg, and returns the field names.
+//
+// The field is compiled by the serialization style:
+//   - the enum is the field of the enum itself
+//   - the exploded query array is the repeated field of the primitive or enum items, such as "?id=1&id=2"
+//   - the deepObject object is the nested message field, such as "?filter[name]=x"
+//   - the exploded form object is flattened to the fields of its properties, which are the query parameters themselves
+//   - the other array or object is serialized to the delimited string, such as "?id=1,2", so it is the string field
+func (c *compiler) compileParameterSchema(msg *protobuf.MessageDescriptorProto, fieldName string, param *openapi3.Parameter) ([]string, error) {
+	schemaRef := param.Schema
+	sm, err := param.SerializationMethod()
+	if err != nil {
+		return nil, err
+	}
+
+	var field *protobuf.FieldDescriptorProto
+	schema := schemaRef.Value
+	switch typ := schema.Type; {
+	case isEnum(schema):
+		field, err = c.newEnumField(msg, fieldName, schemaRef)
+		if err != nil {
+			return nil, err
+		}
+
+	case typ == openapi3.TypeArray && param.In == openapi3.ParameterInQuery && sm.Explode:
+		items := schema.Items
+		switch {
+		case items == nil || items.Value == nil:
+			c.warnf("%s parameter: the array without items is not supported, skipped", param.Name)
+			return nil, nil
+		case isEnum(items.Value):
+			field, err = c.newEnumField(msg, fieldName, items)
+		case isBuiltin(items.Value):
+			field, err = c.newBuiltinField(fieldName, items.Value, builtinFieldType(items.Value))
+		default:
+			c.warnf("%s parameter: unsupported %q items type, skipped", param.Name, items.Value.Type)
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		field.SetRepeated()
+
+	case typ == openapi3.TypeObject && sm.Style == openapi3.SerializationDeepObject:
+		field, err = c.newDeepObjectField(msg, fieldName, schemaRef)
+		if err != nil {
+			return nil, err
+		}
+		if field == nil {
+			c.warnf("%s parameter: the object has no field, skipped", param.Name)
+			return nil, nil
+		}
+
+	case isOneOf(schema), isAnyOf(schema), isAllOf(schema):
+		// the param description is the field comment of the inline schema
+		if desc := param.Description; desc != "" && schemaRef.Ref == "" && schema.Description == "" {
+			schema := *schema
+			schema.Description = desc
+			schemaRef = &openapi3.SchemaRef{Value: &schema}
+		}
+
+		// the param is compiled as the single property of the object
+		object := &openapi3.Schema{
+			Type:       openapi3.TypeObject,
+			Properties: openapi3.Schemas{fieldName: schemaRef},
+		}
+		field, err := c.compileProperty(msg, object, fieldName, schemaRef)
+		if err != nil || field == nil {
+			return nil, err
+		}
+		return []string{field.GetName()}, nil
+
+	case typ == openapi3.TypeObject && sm.Style == openapi3.SerializationForm && sm.Explode:
+		var fields []string
+		for _, propName := range propertyNames(schema.Properties) {
+			field, err := c.compileProperty(msg, schema, propName, schema.Properties[propName])
+			if err != nil {
+				return nil, err
+			}
+			if field != nil {
+				fields = append(fields, field.GetName())
+			}
+		}
+		return fields, nil
+
+	case typ == openapi3.TypeArray || typ == openapi3.TypeObject:
+		field = protobuf.NewFieldDescriptorProto(fieldName, protobuf.FieldTypeString())
+		comment := fmt.Sprintf("%s serialized in the %s style", typ, sm.Style)
+		if desc := param.Description; desc != "" {
+			comment = fmt.Sprintf("%s.\nThe %s is %s", strings.TrimSuffix(desc, "."), typ, comment[len(typ)+1:])
+		}
+		field.AddLeadingComment(field.GetName(), comment)
+		msg.AddField(field)
+		return []string{field.GetName()}, nil
+
+	default:
+		c.warnf("%s parameter: unsupported %q type, skipped", param.Name, typ)
+		return nil, nil
+	}
+
+	desc := param.Description
+	if desc == "" {
+		desc = schema.Description
+	}
+	if desc != "" {
+		field.AddLeadingComment(field.GetName(), desc)
+	}
+	msg.AddField(field)
+
+	return []string{field.GetName()}, nil
+}
+
+// newDeepObjectField returns the fieldName field of the message compiled from the objectRef schema.
+//
+// The message of the inline object is added to the msg as the nested message, and the component object is compiled by CompileComponents.
+// The returned field is nil if the object is skipped, such as the component which can not be compiled.
+func (c *compiler) newDeepObjectField(msg *protobuf.MessageDescriptorProto, fieldName string, objectRef *openapi3.SchemaRef) (*protobuf.FieldDescriptorProto, error) {
+	var objMsg *protobuf.MessageDescriptorProto
+	var err error
+	switch ref := objectRef.Ref; ref {
+	case "":
+		objMsg, err = c.CompileObject(conv.NormalizeMessageName(fieldName), objectRef.Value)
+	default:
+		objMsg, err = c.CompileSchemaRef(pathpkg.Base(ref), objectRef)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("compile object: %w", err)
+	}
+	if skipMessage(objMsg) {
+		return nil, nil
+	}
+	if objectRef.Ref == "" {
+		msg.AddNestedMessage(objMsg)
+	}
+
+	field := protobuf.NewFieldDescriptorProto(fieldName, protobuf.FieldTypeMessage())
+	field.SetTypeName(objMsg.GetName())
+
+	return field, nil
+}
+
+// newEnumField returns the fieldName field whose type is the enum of the enumRef schema.
+//
+// The enum is compiled to the message which has only the enum, the same as the enum schema.
+// The message of the inline enum is added to the msg as the nested message, and the component enum is compiled by CompileComponents.
+func (c *compiler) newEnumField(msg *protobuf.MessageDescriptorProto, fieldName string, enumRef *openapi3.SchemaRef) (*protobuf.FieldDescriptorProto, error) {
+	name := conv.NormalizeMessageName(fieldName)
+	if ref := enumRef.Ref; ref != "" {
+		name = pathpkg.Base(ref)
+	}
+	enumMsg, err := c.CompileSchemaRef(name, enumRef)
+	if err != nil {
+		return nil, fmt.Errorf("compile enum: %w", err)
+	}
+	if enumMsg == nil || enumMsg.GetEnumTypeName() == "" {
+		return nil, fmt.Errorf("%s is not compiled to the enum", name)
+	}
+	if enumRef.Ref == "" {
+		msg.AddNestedMessage(enumMsg)
+	}
+
+	field := protobuf.NewFieldDescriptorProto(fieldName, protobuf.FieldTypeEnum())
+	field.SetTypeName(enumMsg.GetName() + "." + enumMsg.GetEnumTypeName())
+
+	return field, nil
+}
+
+// hasNonPathParameter reports whether the op has the parameter which is not in the path, such as the query parameter.
+func (c *compiler) hasNonPathParameter(op *openapi3.Operation) bool {
+	for _, param := range op.Parameters {
+		paramVal := param.Value
+		if ref := param.Ref; ref != "" {
+			if p, ok := c.components.Parameters[pathpkg.Base(ref)]; ok {
+				paramVal = p.Value
+			}
+		}
+		if paramVal != nil && paramVal.In != openapi3.ParameterInPath {
+			return true
+		}
+	}
+
+	return false
+}
+
+// compileRequestBody compiles the request message of the rb request body, and returns the body field name of the "google.api.http" annotation.
+//
+// The request body is compiled by the media type:
+//   - the binary or opaque payload is the "body" field of google.api.HttpBody
+//   - the fields of the inline object, such as the multipart parts, are added to the request message, and the body is "*"
+//   - the component schema is the field of its message
+//   - the other inline schema is the "body" field of the methName + "Body" message, or its primitive type
+//
+// The inline object is not flattened if hasNonPathParam is true, because the "*" body also takes the query parameters.
+func (c *compiler) compileRequestBody(inputMsgName, methName string, rb *openapi3.RequestBodyRef, hasNonPathParam bool) (*protobuf.MessageDescriptorProto, string, error) {
+	inputMsg := protobuf.NewMessageDescriptorProto(inputMsgName)
+	if rb == nil {
+		return inputMsg, "", nil
+	}
+	reqBody := rb.Value
+	if rb.Ref != "" {
+		if ref, ok := c.components.RequestBodies[pathpkg.Base(rb.Ref)]; ok {
+			reqBody = ref.Value
+		}
+	}
+	if reqBody == nil {
+		return inputMsg, "", nil
+	}
+
+	mediaType, content, ok := c.selectMediaType(reqBody.Content)
+	if !ok {
+		c.warnf("request body: no content of the preferred media types, skipped")
+		return inputMsg, "", nil
+	}
+
+	var field *protobuf.FieldDescriptorProto
+	schemaRef := content.Schema
+	switch {
+	case isOpaqueMediaType(mediaType):
+		field = protobuf.NewFieldDescriptorProto("body", protobuf.FieldTypeMessage())
+		field.SetTypeName(prototype.HttpBody)
+		c.fdesc.AddDependency(prototype.HttpBodyProto)
+
+	case schemaRef == nil || schemaRef.Value == nil:
+		return inputMsg, "", nil
+
+	case schemaRef.Ref != "":
+		refMsg, err := c.CompileSchemaRef(pathpkg.Base(schemaRef.Ref), schemaRef)
+		if err != nil {
+			return nil, "", fmt.Errorf("compile request body: %w", err)
+		}
+		if skipMessage(refMsg) {
+			return inputMsg, "", nil
+		}
+		fieldName := schemaRef.Value.Title
+		if fieldName == "" {
+			fieldName = pathpkg.Base(schemaRef.Ref)
+		}
+		field = protobuf.NewFieldDescriptorProto(conv.NormalizeFieldName(fieldName), protobuf.FieldTypeMessage())
+		field.SetTypeName(refMsg.GetName()) // compiled by CompileComponents
+
+	case schemaRef.Value.Type == openapi3.TypeObject && !isAllOf(schemaRef.Value) && !hasNonPathParam:
+		msg, err := c.CompileObject(inputMsgName, schemaRef.Value)
+		if err != nil {
+			return nil, "", fmt.Errorf("compile request body: %w", err)
+		}
+		msg.SetName(inputMsgName)
+		return msg, "*", nil
+
+	case isBuiltin(schemaRef.Value):
+		var err error
+		field, err = c.newBuiltinField("body", schemaRef.Value, builtinFieldType(schemaRef.Value))
+		if err != nil {
+			return nil, "", fmt.Errorf("compile request body: %w", err)
+		}
+
+	default:
+		bodyMsg, err := c.CompileSchemaRef(methName+"Body", schemaRef)
+		if err != nil {
+			return nil, "", fmt.Errorf("compile request body: %w", err)
+		}
+		if skipMessage(bodyMsg) {
+			return inputMsg, "", nil
+		}
+		bodyMsg.SetName(methName + "Body")
+		c.fdesc.AddMessage(bodyMsg)
+		field = protobuf.NewFieldDescriptorProto("body", protobuf.FieldTypeMessage())
+		field.SetTypeName(bodyMsg.GetName())
+	}
+
+	if desc := reqBody.Description; desc != "" {
+		field.AddLeadingComment(field.GetName(), desc)
+	}
+	inputMsg.AddField(field)
+
+	return inputMsg, field.GetName(), nil
+}
+
+// httpRule returns the "google.api.http" rule of the meth and path operation.
+//
+// The OpenAPI path template parameters are rewritten to the field names of the request message.
+// The body is the field name of the request body, or "*" if the whole request message is the body.
+func httpRule(meth, path string, pathFields map[string]string, body string) *annotations.HttpRule {
+	tmpl := pathParamRe.ReplaceAllStringFunc(path, func(s string) string {
+		name := s[1 : len(s)-1]
+		if field, ok := pathFields[name]; ok {
+			return "{" + field + "}"
+		}
+		return "{" + conv.NormalizeFieldName(name) + "}"
+	})
+
+	rule := &annotations.HttpRule{
+		Body: body,
+	}
+	switch meth {
+	case http.MethodGet:
+		rule.Pattern = &annotations.HttpRule_Get{Get: tmpl}
+	case http.MethodPut:
+		rule.Pattern = &annotations.HttpRule_Put{Put: tmpl}
+	case http.MethodPost:
+		rule.Pattern = &annotations.HttpRule_Post{Post: tmpl}
+	case http.MethodDelete:
+		rule.Pattern = &annotations.HttpRule_Delete{Delete: tmpl}
+	case http.MethodPatch:
+		rule.Pattern = &annotations.HttpRule_Patch{Patch: tmpl}
+	default:
+		rule.Pattern = &annotations.HttpRule_Custom{
+			Custom: &annotations.CustomHttpPattern{
+				Kind: meth,
+				Path: tmpl,
+			},
+		}
+	}
+
+	return rule
+}
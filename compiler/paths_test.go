@@ -0,0 +1,451 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestCompileParameters(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    get:
+      parameters:
+        - name: ids
+          in: query
+          schema: {type: array, items: {type: integer}}
+        - name: colors
+          in: query
+          explode: false
+          schema: {type: array, items: {type: string}}
+        - name: status
+          in: query
+          schema: {type: string, enum: [available, sold]}
+        - name: kinds
+          in: query
+          schema: {type: array, items: {$ref: '#/components/schemas/Kind'}}
+        - name: filter
+          in: query
+          style: deepObject
+          schema:
+            type: object
+            properties:
+              name: {type: string}
+        - name: page
+          in: query
+          schema:
+            type: object
+            properties:
+              offset: {type: integer}
+              limit: {type: integer}
+      responses:
+        "204": {description: no content}
+components:
+  schemas:
+    Kind:
+      type: string
+      enum: [dog, cat]
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.GetItemsRequest")
+	if msg == nil {
+		t.Fatal("not found GetItemsRequest message")
+	}
+
+	tests := map[string]struct {
+		label    descriptorpb.FieldDescriptorProto_Label
+		typ      descriptorpb.FieldDescriptorProto_Type
+		typeName string
+	}{
+		"ids":    {label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED, typ: descriptorpb.FieldDescriptorProto_TYPE_INT32},
+		"colors": {label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, typ: descriptorpb.FieldDescriptorProto_TYPE_STRING},
+		"status": {label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, typ: descriptorpb.FieldDescriptorProto_TYPE_ENUM, typeName: "test.GetItemsRequest.Status.Status"},
+		"kinds":  {label: descriptorpb.FieldDescriptorProto_LABEL_REPEATED, typ: descriptorpb.FieldDescriptorProto_TYPE_ENUM, typeName: "test.Kind.Kind"},
+		"filter": {label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, typ: descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, typeName: "test.GetItemsRequest.Filter"},
+		"offset": {label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, typ: descriptorpb.FieldDescriptorProto_TYPE_INT32},
+		"limit":  {label: descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL, typ: descriptorpb.FieldDescriptorProto_TYPE_INT32},
+	}
+	for fieldName, tt := range tests {
+		fieldName, tt := fieldName, tt
+		t.Run(fieldName, func(t *testing.T) {
+			field := msg.FindFieldByName(fieldName)
+			if field == nil {
+				t.Fatalf("not found %s field", fieldName)
+			}
+			if got := field.GetLabel(); got != tt.label {
+				t.Errorf("got %s label but want %s", got, tt.label)
+			}
+			if got := field.GetType(); got != tt.typ {
+				t.Errorf("got %s type but want %s", got, tt.typ)
+			}
+
+			var typeName string
+			switch {
+			case field.GetEnumType() != nil:
+				typeName = field.GetEnumType().GetFullyQualifiedName()
+			case field.GetMessageType() != nil:
+				typeName = field.GetMessageType().GetFullyQualifiedName()
+			}
+			if typeName != tt.typeName {
+				t.Errorf("got %q type name but want %q", typeName, tt.typeName)
+			}
+		})
+	}
+
+	// the exploded form object is flattened to its properties
+	if field := msg.FindFieldByName("page"); field != nil {
+		t.Errorf("got page field but want offset and limit fields")
+	}
+
+	// the deepObject of the component which can not be compiled is skipped, and only the component is the error
+	const uncompilable = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    get:
+      parameters:
+        - name: owner
+          in: query
+          style: deepObject
+          schema: {$ref: '#/components/schemas/Owner'}
+      responses:
+        "204": {description: no content}
+components:
+  schemas:
+    Owner:
+      type: object
+      properties:
+        name:
+          type: string
+          x-protobuf-field-number: 0
+`
+	_, err := compileSpec(t, uncompilable)
+	var diags Diagnostics
+	if !errors.As(err, &diags) {
+		t.Fatalf("got %v error but want Diagnostics", err)
+	}
+	want := map[string]Severity{
+		"#/paths/~1items/get/parameters/0": SeverityWarning,
+		"#/components/schemas/Owner":       SeverityError,
+	}
+	got := make(map[string]Severity)
+	for _, d := range diags {
+		got[d.Pointer] = d.Severity
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v diagnostics but want %v: %v", got, want, diags)
+	}
+}
+
+func TestCompileRequestBody(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /pets:
+    post:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+      responses:
+        "204": {description: no content}
+  /pets/{petId}:
+    post:
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: string}}
+        - {name: dryRun, in: query, schema: {type: boolean}}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              properties:
+                name: {type: string}
+      responses:
+        "204": {description: no content}
+`
+
+	result := mustCompileSpec(t, src, WithAnnotation(true))
+	validateDescriptorSet(t, result)
+
+	svc := result.FileDescriptor.FindService("test.TestService")
+	if svc == nil {
+		t.Fatal("not found TestService service")
+	}
+
+	tests := map[string]struct {
+		body   string
+		fields []string
+	}{
+		// the inline object is flattened into the request message
+		"PostPets": {body: "*", fields: []string{"name"}},
+		// the query parameter must not be taken by the "*" body
+		"PostPetsByPetID": {body: "body", fields: []string{"pet_id", "dry_run", "body"}},
+	}
+	for methName, tt := range tests {
+		methName, tt := methName, tt
+		t.Run(methName, func(t *testing.T) {
+			method := svc.FindMethodByName(methName)
+			if method == nil {
+				t.Fatalf("not found %s method", methName)
+			}
+			rule, ok := proto.GetExtension(method.GetMethodOptions(), annotations.E_Http).(*annotations.HttpRule)
+			if !ok || rule == nil {
+				t.Fatal("not found google.api.http annotation")
+			}
+			if got := rule.GetBody(); got != tt.body {
+				t.Errorf("got %q body but want %q", got, tt.body)
+			}
+
+			fields := method.GetInputType().GetFields()
+			if len(fields) != len(tt.fields) {
+				t.Fatalf("got %d fields but want %v", len(fields), tt.fields)
+			}
+			for i, field := range fields {
+				if field.GetName() != tt.fields[i] {
+					t.Errorf("got %s field at %d but want %s", field.GetName(), i, tt.fields[i])
+				}
+			}
+		})
+	}
+}
+
+func TestCompileOperationDiagnostics(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    get:
+      parameters:
+        - name: id
+          in: query
+          schema: {type: string}
+        - name: raw
+          in: query
+          content:
+            text/plain: {}
+      responses:
+        "200":
+          description: ok
+          content:
+            text/plain:
+              schema: {type: string}
+        "404":
+          description: not found
+          content:
+            text/plain:
+              schema: {type: string}
+`
+
+	result := mustCompileSpec(t, src, WithMediaTypes([]string{"application/json"}))
+
+	want := map[string]int{ // pointer to the line
+		"#/paths/~1items/get/parameters/1":  11,
+		"#/paths/~1items/get/responses/200": 16,
+		"#/paths/~1items/get/responses/404": 21,
+	}
+	got := make(map[string]int)
+	for _, d := range result.Diagnostics {
+		got[d.Pointer] = d.Line
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v diagnostics but want %v: %v", got, want, result.Diagnostics)
+	}
+}
+
+func TestHTTPRule(t *testing.T) {
+	tests := map[string]struct {
+		meth       string
+		path       string
+		pathFields map[string]string
+		body       string
+		want       *annotations.HttpRule
+	}{
+		"get": {
+			meth:       http.MethodGet,
+			path:       "/pets/{petId}",
+			pathFields: map[string]string{"petId": "pet_id"},
+			want:       &annotations.HttpRule{Pattern: &annotations.HttpRule_Get{Get: "/pets/{pet_id}"}},
+		},
+		"post body": {
+			meth: http.MethodPost,
+			path: "/pets",
+			body: "*",
+			want: &annotations.HttpRule{Pattern: &annotations.HttpRule_Post{Post: "/pets"}, Body: "*"},
+		},
+		"patch body field": {
+			meth:       http.MethodPatch,
+			path:       "/pets/{petId}",
+			pathFields: map[string]string{"petId": "id"},
+			body:       "body",
+			want:       &annotations.HttpRule{Pattern: &annotations.HttpRule_Patch{Patch: "/pets/{id}"}, Body: "body"},
+		},
+		"unknown path parameter": {
+			meth: http.MethodDelete,
+			path: "/owners/{ownerId}/pets",
+			want: &annotations.HttpRule{Pattern: &annotations.HttpRule_Delete{Delete: "/owners/{owner_id}/pets"}},
+		},
+		"custom": {
+			meth: http.MethodHead,
+			path: "/pets",
+			want: &annotations.HttpRule{Pattern: &annotations.HttpRule_Custom{Custom: &annotations.CustomHttpPattern{Kind: http.MethodHead, Path: "/pets"}}},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			if got := httpRule(tt.meth, tt.path, tt.pathFields, tt.body); !proto.Equal(got, tt.want) {
+				t.Errorf("got %v rule but want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// skipRPCSpec is the OpenAPI document which has the deprecated operation.
+const skipRPCSpec = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /pets:
+    get:
+      parameters:
+        - {name: limit, in: query, schema: {type: integer}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: object, properties: {next: {type: string}}}
+    delete:
+      deprecated: true
+      parameters:
+        - {name: all, in: query, schema: {type: boolean}}
+      responses:
+        "200":
+          description: ok
+          content:
+            application/json:
+              schema: {type: object, properties: {count: {type: integer}}}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        name: {type: string}
+`
+
+func TestCompileSkipRPC(t *testing.T) {
+	tests := map[string]struct {
+		options  []Option
+		methods  []string
+		messages []string
+	}{
+		"default": {
+			methods:  []string{"GetPets", "DeletePets"},
+			messages: []string{"GetPetsRequest", "GetPetsResponse", "DeletePetsRequest", "DeletePetsResponse", "Pet"},
+		},
+		"skip RPC": {
+			options:  []Option{WithSkipRPC(true)},
+			messages: []string{"Pet"},
+		},
+		"skip deprecated RPC": {
+			options:  []Option{WithSkipDeprecatedRPC(true)},
+			methods:  []string{"GetPets"},
+			messages: []string{"GetPetsRequest", "GetPetsResponse", "Pet"},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			result := mustCompileSpec(t, skipRPCSpec, tt.options...)
+			validateDescriptorSet(t, result)
+
+			var methods []string
+			for _, svc := range result.FileDescriptor.GetServices() {
+				for _, method := range svc.GetMethods() {
+					methods = append(methods, method.GetName())
+				}
+			}
+			if !reflect.DeepEqual(methods, tt.methods) {
+				t.Errorf("got %v methods but want %v", methods, tt.methods)
+			}
+
+			var messages []string
+			for _, msg := range result.FileDescriptor.GetMessageTypes() {
+				messages = append(messages, msg.GetName())
+			}
+			if !reflect.DeepEqual(messages, tt.messages) {
+				t.Errorf("got %v messages but want %v", messages, tt.messages)
+			}
+		})
+	}
+}
+
+func TestCompileInlineParameters(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /pets/{petId}:
+    get:
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: string}}
+        - {name: verbose, in: query, schema: {type: boolean}}
+        - $ref: '#/components/parameters/Limit'
+      responses:
+        "204": {description: no content}
+components:
+  parameters:
+    Limit:
+      name: limit
+      in: query
+      schema: {type: integer, format: int64}
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	msg := result.FileDescriptor.FindMessage("test.GetPetsByPetIDRequest")
+	if msg == nil {
+		t.Fatal("not found GetPetsByPetIDRequest message")
+	}
+
+	// the inline parameters are compiled with the same type mapping as the component parameter
+	tests := map[string]descriptorpb.FieldDescriptorProto_Type{
+		"pet_id":  descriptorpb.FieldDescriptorProto_TYPE_STRING,
+		"verbose": descriptorpb.FieldDescriptorProto_TYPE_BOOL,
+		"limit":   descriptorpb.FieldDescriptorProto_TYPE_INT64,
+	}
+	if got, want := len(msg.GetFields()), len(tests); got != want {
+		t.Fatalf("got %d fields but want %d", got, want)
+	}
+	for fieldName, typ := range tests {
+		field := msg.FindFieldByName(fieldName)
+		if field == nil {
+			t.Fatalf("not found %s field", fieldName)
+		}
+		if got := field.GetType(); got != typ {
+			t.Errorf("%s: got %s type but want %s", fieldName, got, typ)
+		}
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+
+	"go.lsp.dev/openapi2protobuf/internal/conv"
+	"go.lsp.dev/openapi2protobuf/protobuf"
+	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
+)
+
+// The following are the candidates of the success response rule, in addition to the explicit status code such as "201".
+const (
+	// SuccessLowest selects the response of the lowest 2xx status code, such as "200" or "201".
+	SuccessLowest = "lowest"
+
+	// Success2XX selects the "2XX" range response.
+	Success2XX = "2XX"
+
+	// SuccessDefault selects the "default" response.
+	SuccessDefault = "default"
+)
+
+// DefaultSuccessResponses is the default success response rule.
+var DefaultSuccessResponses = []string{SuccessLowest, Success2XX, SuccessDefault}
+
+// validateSuccessResponses validates the candidates of the success response rule.
+func validateSuccessResponses(rule []string) error {
+	for _, candidate := range rule {
+		switch candidate {
+		case SuccessLowest, Success2XX, SuccessDefault:
+			// nothing to do
+		default:
+			if code, ok := statusCode(candidate); !ok || code < 200 || code > 299 {
+				return fmt.Errorf("invalid success response %q, must be %s, %s, %s or 2xx status code", candidate, SuccessLowest, Success2XX, SuccessDefault)
+			}
+		}
+	}
+
+	return nil
+}
+
+// statusCode parses the explicit status code of the status, such as "404".
+func statusCode(status string) (int, bool) {
+	if len(status) != 3 {
+		return 0, false
+	}
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return 0, false
+	}
+
+	return code, true
+}
+
+// selectSuccessResponse returns the status and response of the first candidate of the rule which the responses has.
+func selectSuccessResponse(responses openapi3.Responses, rule []string) (string, *openapi3.ResponseRef) {
+	for _, candidate := range rule {
+		if candidate != SuccessLowest {
+			if resp, ok := responses[candidate]; ok {
+				return candidate, resp
+			}
+			continue
+		}
+
+		lowest := ""
+		for status := range responses {
+			if code, ok := statusCode(status); ok && code >= 200 && code <= 299 && (lowest == "" || status < lowest) {
+				lowest = status
+			}
+		}
+		if lowest != "" {
+			return lowest, responses[lowest]
+		}
+	}
+
+	return "", nil
+}
+
+// isErrorStatus reports whether the status is the 4xx or 5xx status code, or "4XX" or "5XX" range.
+func isErrorStatus(status string) bool {
+	switch status {
+	case "4XX", "5XX":
+		return true
+	}
+	code, ok := statusCode(status)
+
+	return ok && code >= 400 && code <= 599
+}
+
+// errorMessageSuffix returns the suffix of the error detail message name of the status, such as "NotFoundError" of "404".
+func errorMessageSuffix(status string) string {
+	switch status {
+	case "4XX":
+		return "ClientError"
+	case "5XX":
+		return "ServerError"
+	case SuccessDefault:
+		return "DefaultError"
+	}
+
+	code, _ := statusCode(status)
+	text := http.StatusText(code)
+	if text == "" {
+		return "Status" + status + "Error"
+	}
+
+	return strings.TrimSuffix(conv.NormalizeMessageName(strings.ReplaceAll(text, " ", "_")), "Error") + "Error"
+}
+
+// resolveResponse returns the response of the ref, which may refer to the component response.
+func (c *compiler) resolveResponse(ref *openapi3.ResponseRef) *openapi3.Response {
+	if ref == nil {
+		return nil
+	}
+	if ref.Ref != "" {
+		if resp, ok := c.components.Responses[path.Base(ref.Ref)]; ok {
+			return resp.Value
+		}
+	}
+
+	return ref.Value
+}
+
+// responseContent returns the media type and content of the resp selected by the media type preference.
+//
+// The content is nil if the resp has no body, and responseContent reports false if the resp has no content of the preferred media types.
+func (c *compiler) responseContent(resp *openapi3.Response) (string, *openapi3.MediaType, bool) {
+	if resp == nil || len(resp.Content) == 0 {
+		return "", nil, true
+	}
+
+	return c.selectMediaType(resp.Content)
+}
+
+// compileSuccessResponse compiles the success response of the op to the outputMsgName message, and returns the output type name of the RPC method.
+//
+// The output type is google.protobuf.Empty if the success response has no body, such as "204 No Content",
+// and google.api.HttpBody if the success response is the binary or opaque payload.
+//
+// The pointer is kept at the response if it can not be compiled, to report the returned error at the response.
+func (c *compiler) compileSuccessResponse(outputMsgName string, op *openapi3.Operation) (_ string, err error) {
+	status, respRef := selectSuccessResponse(op.Responses, c.opt.successResponses)
+	if status != "" {
+		defer func(opPointer string) {
+			if err == nil {
+				c.pointer = opPointer
+			}
+		}(c.pointer)
+		c.pointer = childPointer(c.pointer, "responses", status)
+	}
+	mediaType, content, ok := c.responseContent(c.resolveResponse(respRef))
+	if !ok {
+		c.warnf("%s response: no content of the preferred media types, compiled as %s", status, prototype.Empty)
+	}
+	if content != nil && isOpaqueMediaType(mediaType) {
+		c.fdesc.AddDependency(prototype.HttpBodyProto)
+		return prototype.HttpBody, nil
+	}
+
+	var schemaRef *openapi3.SchemaRef
+	if content != nil {
+		schemaRef = content.Schema
+	}
+	if schemaRef == nil || schemaRef.Value == nil {
+		c.fdesc.AddDependency(prototype.EmptyProto)
+		return prototype.Empty, nil
+	}
+	val := schemaRef.Value
+
+	// the inline message schema is the output message itself
+	if isAllOf(val) || (schemaRef.Ref == "" && !isBuiltin(val)) {
+		msg, err := c.CompileSchemaRef(outputMsgName, &openapi3.SchemaRef{Value: val})
+		if err != nil {
+			return "", fmt.Errorf("compile %s response: %w", status, err)
+		}
+		if skipMessage(msg) {
+			c.fdesc.AddDependency(prototype.EmptyProto)
+			return prototype.Empty, nil
+		}
+		msg.SetName(outputMsgName)
+		c.fdesc.AddMessage(msg)
+		return outputMsgName, nil
+	}
+
+	// the inline primitive schema is wrapped by the output message
+	if schemaRef.Ref == "" {
+		msg, err := c.compilePrimitiveResponse(outputMsgName, schemaRef)
+		if err != nil {
+			return "", fmt.Errorf("compile %s response: %w", status, err)
+		}
+		c.fdesc.AddMessage(msg)
+		return outputMsgName, nil
+	}
+
+	refMsg, err := c.CompileSchemaRef(path.Base(schemaRef.Ref), schemaRef)
+	if err != nil {
+		return "", fmt.Errorf("compile %s response: %w", status, err)
+	}
+	if skipMessage(refMsg) {
+		c.fdesc.AddDependency(prototype.EmptyProto)
+		return prototype.Empty, nil
+	}
+	field := protobuf.NewFieldDescriptorProto(valueFieldName(schemaRef), protobuf.FieldTypeMessage())
+	field.SetTypeName(refMsg.GetName()) // compiled by CompileComponents
+	c.fdesc.AddMessage(newValueMessage(outputMsgName, field, val))
+
+	return outputMsgName, nil
+}
+
+// valueFieldName returns the field name of the response schemaRef which is wrapped by the message,
+// which is the title, the referenced component name or "value".
+func valueFieldName(schemaRef *openapi3.SchemaRef) string {
+	fieldName := schemaRef.Value.Title
+	if fieldName == "" {
+		fieldName = "value"
+		if ref := schemaRef.Ref; ref != "" {
+			fieldName = path.Base(ref)
+		}
+	}
+
+	return conv.NormalizeFieldName(fieldName)
+}
+
+// compilePrimitiveResponse compiles the inline primitive response schemaRef to the msgName message which wraps it.
+func (c *compiler) compilePrimitiveResponse(msgName string, schemaRef *openapi3.SchemaRef) (*protobuf.MessageDescriptorProto, error) {
+	val := schemaRef.Value
+	field, err := c.newBuiltinField(valueFieldName(schemaRef), val, builtinFieldType(val))
+	if err != nil {
+		return nil, err
+	}
+
+	return newValueMessage(msgName, field, val), nil
+}
+
+// newValueMessage returns the msgName message which has the single field of the response schema val.
+func newValueMessage(msgName string, field *protobuf.FieldDescriptorProto, val *openapi3.Schema) *protobuf.MessageDescriptorProto {
+	switch {
+	case val.Description != "":
+		field.AddLeadingComment(field.GetName(), val.Description)
+	case val.Title != "":
+		field.AddLeadingComment(field.GetName(), val.Title)
+	}
+
+	msg := protobuf.NewMessageDescriptorProto(msgName)
+	msg.AddField(field)
+	if title := val.Title; title != "" {
+		msg.AddLeadingComment(msg.GetName(), title)
+	}
+
+	return msg
+}
+
+// compileErrorResponses compiles the 4xx and 5xx responses of the op to the error detail messages, and returns the comment lines which document them.
+//
+// The "default" response is also the error response unless it is selected as the success response.
+// The component schema is used as is, and the inline schema is compiled to the message named by the methName and status, such as "GetPetNotFoundError".
+// The pointer is kept at the response if it can not be compiled, to report the returned error at the response.
+func (c *compiler) compileErrorResponses(methName string, op *openapi3.Operation) ([]string, error) {
+	success, _ := selectSuccessResponse(op.Responses, c.opt.successResponses)
+
+	statuses := make([]string, 0, len(op.Responses))
+	for status := range op.Responses {
+		if isErrorStatus(status) || (status == SuccessDefault && success != SuccessDefault) {
+			statuses = append(statuses, status)
+		}
+	}
+	sort.Strings(statuses) // "default" is sorted after the status codes
+
+	var lines []string
+	opPointer := c.pointer
+	for _, status := range statuses {
+		c.pointer = childPointer(opPointer, "responses", status)
+
+		text := status
+		if code, ok := statusCode(status); ok {
+			text += " " + http.StatusText(code)
+		}
+
+		mediaType, content, ok := c.responseContent(c.resolveResponse(op.Responses[status]))
+		if !ok {
+			c.warnf("%s response: no content of the preferred media types, skipped", status)
+			continue
+		}
+		if content != nil && isOpaqueMediaType(mediaType) {
+			lines = append(lines, fmt.Sprintf("  %s: %s", strings.TrimSpace(text), prototype.HttpBody))
+			continue
+		}
+		if content == nil || content.Schema == nil || content.Schema.Value == nil {
+			continue
+		}
+		schemaRef := content.Schema
+
+		var msg *protobuf.MessageDescriptorProto
+		var err error
+		switch ref := schemaRef.Ref; {
+		case ref == "" && isBuiltin(schemaRef.Value):
+			msg, err = c.compilePrimitiveResponse(methName+errorMessageSuffix(status), schemaRef)
+		case ref == "":
+			msg, err = c.CompileSchemaRef(methName+errorMessageSuffix(status), schemaRef)
+		default:
+			msg, err = c.CompileSchemaRef(path.Base(ref), schemaRef)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("compile %s response: %w", status, err)
+		}
+		if skipMessage(msg) {
+			continue
+		}
+		if schemaRef.Ref == "" {
+			msg.SetName(methName + errorMessageSuffix(status))
+			c.fdesc.AddMessage(msg)
+		}
+
+		lines = append(lines, fmt.Sprintf("  %s: %s", strings.TrimSpace(text), msg.GetName()))
+	}
+	c.pointer = opPointer
+
+	if len(lines) == 0 {
+		return nil, nil
+	}
+
+	return append([]string{"Error details of google.rpc.Status:"}, lines...), nil
+}
@@ -0,0 +1,150 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func TestSelectSuccessResponse(t *testing.T) {
+	tests := map[string]struct {
+		statuses []string
+		rule     []string
+		want     string
+	}{
+		"lowest": {
+			statuses: []string{"202", "201", "2XX", "default"},
+			rule:     DefaultSuccessResponses,
+			want:     "201",
+		},
+		"2XX": {
+			statuses: []string{"2XX", "404", "default"},
+			rule:     DefaultSuccessResponses,
+			want:     "2XX",
+		},
+		"default": {
+			statuses: []string{"404", "default"},
+			rule:     DefaultSuccessResponses,
+			want:     "default",
+		},
+		"status code": {
+			statuses: []string{"200", "201"},
+			rule:     []string{"201", SuccessLowest},
+			want:     "201",
+		},
+		"not found": {
+			statuses: []string{"404", "default"},
+			rule:     []string{SuccessLowest, Success2XX},
+			want:     "",
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			responses := make(openapi3.Responses, len(tt.statuses))
+			for _, status := range tt.statuses {
+				responses[status] = &openapi3.ResponseRef{Value: openapi3.NewResponse()}
+			}
+			if got, _ := selectSuccessResponse(responses, tt.rule); got != tt.want {
+				t.Errorf("got %q success response but want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileResponses(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /items:
+    get:
+      responses:
+        "201":
+          description: created
+          content:
+            application/json:
+              schema: {type: object, properties: {id: {type: integer}}}
+        "202":
+          description: accepted
+          content:
+            application/json:
+              schema: {type: string}
+        "404":
+          description: not found
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Error'}
+        "500":
+          description: server error
+          content:
+            application/json:
+              schema: {type: string}
+        default:
+          description: unexpected error
+          content:
+            application/json:
+              schema: {type: object, properties: {code: {type: integer}}}
+    delete:
+      responses:
+        "204": {description: no content}
+components:
+  schemas:
+    Error:
+      type: object
+      properties:
+        message: {type: string}
+`
+
+	result := mustCompileSpec(t, src)
+	validateDescriptorSet(t, result)
+
+	svc := result.FileDescriptor.FindService("test.TestService")
+	if svc == nil {
+		t.Fatal("not found TestService service")
+	}
+
+	// the lowest 2xx response is selected, and the others are the error details
+	get := svc.FindMethodByName("GetItems")
+	if get == nil {
+		t.Fatal("not found GetItems method")
+	}
+	if got, want := get.GetOutputType().GetName(), "GetItemsResponse"; got != want {
+		t.Errorf("got %s output type but want %s", got, want)
+	}
+	if get.GetOutputType().FindFieldByName("id") == nil {
+		t.Error("not found id field of the 201 response")
+	}
+	const wantComment = ` Error details of google.rpc.Status:
+   404 Not Found: Error
+   500 Internal Server Error: GetItemsInternalServerError
+   default: GetItemsDefaultError`
+	if got := get.GetSourceInfo().GetLeadingComments(); got != wantComment {
+		t.Errorf("got %q comment but want %q", got, wantComment)
+	}
+
+	// the inline primitive error is wrapped by the message as the success response
+	errMsg := result.FileDescriptor.FindMessage("test.GetItemsInternalServerError")
+	if errMsg == nil {
+		t.Fatal("not found GetItemsInternalServerError message")
+	}
+	if field := errMsg.FindFieldByName("value"); field == nil || field.GetType() != descriptorpb.FieldDescriptorProto_TYPE_STRING {
+		t.Errorf("got %v fields but want value string field", errMsg.GetFields())
+	}
+	if result.FileDescriptor.FindMessage("test.GetItemsDefaultError").FindFieldByName("code") == nil {
+		t.Error("not found code field of the default response")
+	}
+
+	// the response without body is google.protobuf.Empty
+	del := svc.FindMethodByName("DeleteItems")
+	if del == nil {
+		t.Fatal("not found DeleteItems method")
+	}
+	if got, want := del.GetOutputType().GetFullyQualifiedName(), "google.protobuf.Empty"; got != want {
+		t.Errorf("got %s output type but want %s", got, want)
+	}
+}
@@ -0,0 +1,171 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/jhump/protoreflect/desc"
+	"github.com/jhump/protoreflect/desc/protoprint"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+// Result represents a result of the compiled Protocol Buffers.
+type Result struct {
+	// FileDescriptorProto is the compiled file descriptor proto.
+	FileDescriptorProto *descriptorpb.FileDescriptorProto
+
+	// FileDescriptor is the FileDescriptorProto linked with its dependencies.
+	FileDescriptor *desc.FileDescriptor
+
+	// Source is the Protocol Buffers source rendered with the default RenderOption.
+	Source string
+
+	// FieldNumberLock is the field numbers of the compiled messages, updated from the lock given by WithFieldNumberLock.
+	FieldNumberLock *FieldNumberLock
+
+	// Diagnostics is the warnings found while compiling.
+	Diagnostics Diagnostics
+}
+
+// Format represents an output format of the compiled Protocol Buffers.
+type Format string
+
+const (
+	// FormatProto is the Protocol Buffers source format.
+	FormatProto Format = "proto"
+
+	// FormatDescriptorSet is the serialized descriptorpb.FileDescriptorSet format, includes all transitive dependencies.
+	FormatDescriptorSet Format = "descriptor_set"
+
+	// FormatJSON is the protojson format of the descriptorpb.FileDescriptorProto without the source code info.
+	FormatJSON Format = "json"
+)
+
+// Ext returns the file extension of f.
+func (f Format) Ext() string {
+	switch f {
+	case FormatDescriptorSet:
+		return ".pb"
+	case FormatJSON:
+		return ".json"
+	default:
+		return ".proto"
+	}
+}
+
+// RenderOption represents an idiomatic functional option pattern to render the Protocol Buffers source.
+type RenderOption func(p *protoprint.Printer)
+
+// WithIndent sets the indentation used to render the Protocol Buffers source.
+//
+// The default is two spaces.
+func WithIndent(indent string) RenderOption {
+	return func(p *protoprint.Printer) { p.Indent = indent }
+}
+
+// WithSortElements sets whether the render the elements sorted into a canonical order.
+//
+// See protoprint.Printer.SortElements for the canonical order.
+func WithSortElements(sortElements bool) RenderOption {
+	return func(p *protoprint.Printer) { p.SortElements = sortElements }
+}
+
+// WithCompact sets whether the render the Protocol Buffers source without any blank lines.
+func WithCompact(compact bool) RenderOption {
+	return func(p *protoprint.Printer) { p.Compact = compact }
+}
+
+// newResult returns the new Result and renders the source with the default RenderOption.
+func newResult(fd *descriptorpb.FileDescriptorProto, fdesc *desc.FileDescriptor, lock *FieldNumberLock, diags Diagnostics) (*Result, error) {
+	r := &Result{
+		FileDescriptorProto: fd,
+		FileDescriptor:      fdesc,
+		FieldNumberLock:     lock,
+		Diagnostics:         diags,
+	}
+
+	src, err := r.Render()
+	if err != nil {
+		return nil, err
+	}
+	r.Source = src
+
+	return r, nil
+}
+
+// Render renders the Protocol Buffers source of r.
+func (r *Result) Render(options ...RenderOption) (string, error) {
+	p := &protoprint.Printer{}
+	for _, o := range options {
+		o(p)
+	}
+
+	var sb strings.Builder
+	if err := p.PrintProtoFile(r.FileDescriptor, &sb); err != nil {
+		return "", fmt.Errorf("could not print proto: %w", err)
+	}
+
+	return sb.String(), nil
+}
+
+// WriteTo writes the rendered Protocol Buffers source to w.
+//
+// WriteTo implements io.WriterTo.
+func (r *Result) WriteTo(w io.Writer) (int64, error) {
+	n, err := io.WriteString(w, r.Source)
+	return int64(n), err
+}
+
+// FileDescriptorSet returns the descriptorpb.FileDescriptorSet of r.
+//
+// The returned set includes all transitive dependencies of r in topological order, and the compiled file descriptor is the last.
+//
+// The compiled file descriptor has no source code info, see exportFileDescriptorProto.
+func (r *Result) FileDescriptorSet() *descriptorpb.FileDescriptorSet {
+	set := desc.ToFileDescriptorSet(r.FileDescriptor)
+	set.File[len(set.File)-1] = exportFileDescriptorProto(r.FileDescriptorProto)
+
+	return set
+}
+
+// exportFileDescriptorProto returns the copy of the fd without the source code info.
+//
+// The source code info only holds the comments to render the Protocol Buffers source. Its locations have no span,
+// which is required by the consumers of the file descriptor such as protodesc.
+func exportFileDescriptorProto(fd *descriptorpb.FileDescriptorProto) *descriptorpb.FileDescriptorProto {
+	fd = proto.Clone(fd).(*descriptorpb.FileDescriptorProto)
+	fd.SourceCodeInfo = nil
+
+	return fd
+}
+
+// Marshal returns r encoded in the format.
+func (r *Result) Marshal(format Format) ([]byte, error) {
+	switch format {
+	case FormatProto:
+		return []byte(r.Source), nil
+
+	case FormatDescriptorSet:
+		b, err := proto.Marshal(r.FileDescriptorSet())
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal file descriptor set: %w", err)
+		}
+		return b, nil
+
+	case FormatJSON:
+		b, err := protojson.MarshalOptions{Multiline: true}.Marshal(exportFileDescriptorProto(r.FileDescriptorProto))
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal file descriptor to JSON: %w", err)
+		}
+		return b, nil
+
+	default:
+		return nil, fmt.Errorf("unknown %q format", format)
+	}
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"go.lsp.dev/openapi2protobuf/openapi"
+)
+
+func TestResultMarshalDescriptorSet(t *testing.T) {
+	ctx := context.Background()
+
+	for _, name := range []string{"petstore.yaml", "recursive.yaml"} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			spec, err := openapi.LoadFile(ctx, filepath.Join("..", "testdata", "oai", "v3.0", name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := Compile(ctx, spec, WithPackageName("test"), WithAnnotation(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			validateDescriptorSet(t, result)
+		})
+	}
+}
@@ -4,8 +4,107 @@
 package compiler
 
 import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
 	"github.com/getkin/kin-openapi/openapi3"
+
+	"go.lsp.dev/openapi2protobuf/internal/conv"
+	"go.lsp.dev/openapi2protobuf/protobuf"
 )
 
 // CompileTags compiles tags object.
-func (c *compiler) CompileTags(tags openapi3.Tags) error { return nil }
+//
+// CompileTags keeps the tags to compile the service of each tag, so it must be called before CompilePaths.
+func (c *compiler) CompileTags(tags openapi3.Tags) error {
+	for _, tag := range tags {
+		if tag == nil {
+			continue
+		}
+		c.tags[tag.Name] = tag
+	}
+
+	return nil
+}
+
+// grpcServiceName returns the service name of the x-grpc-service-name extension, and reports whether the extensions has it.
+func grpcServiceName(extensions map[string]interface{}) (string, bool, error) {
+	ext, ok := extensions["x-grpc-service-name"]
+	if !ok {
+		return "", false, nil
+	}
+
+	var name string
+	if err := json.Unmarshal(ext.(json.RawMessage), &name); err != nil {
+		return "", false, fmt.Errorf("unmarshal x-grpc-service-name extension: %w", err)
+	}
+
+	return name, true, nil
+}
+
+// operationService returns the service of the op from the services, or adds the new service to the services.
+//
+// The service is selected in the following order:
+//   - the x-grpc-service-name extension of the op
+//   - the service of the first tag of the op if the servicePerTag option is enabled, which is overridden by the x-grpc-service-name extension of the tag
+//   - the defaultService
+func (c *compiler) operationService(services map[string]*protobuf.ServiceDescriptorProto, defaultService string, op *openapi3.Operation) (*protobuf.ServiceDescriptorProto, error) {
+	name, ok, err := grpcServiceName(op.Extensions)
+	if err != nil {
+		return nil, err
+	}
+
+	var tag *openapi3.Tag
+	if !ok {
+		name = defaultService
+		if c.opt.servicePerTag && len(op.Tags) > 0 {
+			tag = c.tags[op.Tags[0]]
+			if tag == nil {
+				tag = &openapi3.Tag{Name: op.Tags[0]} // the tag which is not declared in the tags object
+			}
+			name = conv.NormalizeMessageName(tag.Name) + "Service"
+			tagName, ok, err := grpcServiceName(tag.Extensions)
+			if err != nil {
+				return nil, fmt.Errorf("%s tag: %w", tag.Name, err)
+			}
+			if ok {
+				name = tagName
+			}
+		}
+	}
+
+	if svc, ok := services[name]; ok {
+		return svc, nil
+	}
+
+	svc := protobuf.NewServiceDescriptorProto(name)
+	if tag != nil {
+		addTagComment(svc, tag)
+	}
+	services[name] = svc
+
+	return svc, nil
+}
+
+// addTagComment adds the description and external docs of the tag to the leading comment of the svc.
+func addTagComment(svc *protobuf.ServiceDescriptorProto, tag *openapi3.Tag) {
+	if desc := tag.Description; desc != "" {
+		svc.AddLeadingComment(svc.GetName(), desc)
+	}
+
+	docs := tag.ExternalDocs
+	if docs == nil || docs.URL == "" {
+		return
+	}
+	see := " See " + docs.URL
+	if desc := docs.Description; desc != "" {
+		see += " for " + strings.TrimSuffix(desc, ".") + "."
+	}
+
+	comment := svc.GetComment()
+	if comment.LeadingComments != "" {
+		comment.LeadingComments += "\n\n"
+	}
+	comment.LeadingComments += see
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"go.lsp.dev/openapi2protobuf/openapi"
+)
+
+func TestCompileServicePerTag(t *testing.T) {
+	ctx := context.Background()
+	spec, err := openapi.LoadFile(ctx, filepath.Join("..", "testdata", "oai", "v3.0", "tags.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct {
+		options  []Option
+		services map[string][]string // service name to method names
+		comments map[string]string   // service name to leading comments
+	}{
+		"service per tag": {
+			options: []Option{WithServicePerTag(true)},
+			// the default TestService is dropped because all operations are tagged
+			services: map[string][]string{
+				"AccountService":  {"GetUsers"},            // x-grpc-service-name of the tag
+				"AdminService":    {"PostAdmin"},           // x-grpc-service-name of the operation
+				"PetStoreService": {"GetPets", "PostPets"}, // the first tag of the operation
+				"StoresService":   {"GetStores"},           // the tag which is not declared in the tags object
+			},
+			comments: map[string]string{
+				"AccountService":  " See https://example.com/users",
+				"AdminService":    "",
+				"PetStoreService": " PetStoreService is the pet store operations.\n\n See https://example.com/pets for the pet guide.",
+				"StoresService":   "",
+			},
+		},
+		"single service": {
+			services: map[string][]string{
+				"AdminService": {"PostAdmin"},
+				"TestService":  {"GetPets", "PostPets", "GetStores", "GetUsers"},
+			},
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			result, err := Compile(ctx, spec, append([]Option{WithPackageName("test")}, tt.options...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			services := make(map[string][]string)
+			comments := make(map[string]string)
+			for _, svc := range result.FileDescriptor.GetServices() {
+				for _, meth := range svc.GetMethods() {
+					services[svc.GetName()] = append(services[svc.GetName()], meth.GetName())
+				}
+				comments[svc.GetName()] = svc.GetSourceInfo().GetLeadingComments()
+			}
+			if !reflect.DeepEqual(services, tt.services) {
+				t.Errorf("got %v services but want %v", services, tt.services)
+			}
+			if tt.comments != nil && !reflect.DeepEqual(comments, tt.comments) {
+				t.Errorf("got %q comments but want %q", comments, tt.comments)
+			}
+		})
+	}
+}
+
+func TestCompileServicePerTagUntagged(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /health:
+    get:
+      responses:
+        "204": {description: no content}
+  /pets:
+    get:
+      tags: [pets]
+      responses:
+        "204": {description: no content}
+`
+
+	result := mustCompileSpec(t, src, WithServicePerTag(true))
+
+	// the default service is kept for the untagged operation
+	for svcName, methName := range map[string]string{"TestService": "GetHealth", "PetsService": "GetPets"} {
+		svc := result.FileDescriptor.FindService("test." + svcName)
+		if svc == nil {
+			t.Fatalf("not found %s service", svcName)
+		}
+		if svc.FindMethodByName(methName) == nil {
+			t.Errorf("not found %s method in %s service", methName, svcName)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"go.lsp.dev/openapi2protobuf/protobuf"
+	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
+)
+
+// wrapperTypes maps the scalar field type to the google.protobuf wrapper message type.
+var wrapperTypes = map[descriptorpb.FieldDescriptorProto_Type]string{
+	descriptorpb.FieldDescriptorProto_TYPE_DOUBLE:   prototype.DoubleValue,
+	descriptorpb.FieldDescriptorProto_TYPE_FLOAT:    prototype.FloatValue,
+	descriptorpb.FieldDescriptorProto_TYPE_INT64:    prototype.Int64Value,
+	descriptorpb.FieldDescriptorProto_TYPE_SINT64:   prototype.Int64Value,
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED64: prototype.Int64Value,
+	descriptorpb.FieldDescriptorProto_TYPE_UINT64:   prototype.UInt64Value,
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED64:  prototype.UInt64Value,
+	descriptorpb.FieldDescriptorProto_TYPE_INT32:    prototype.Int32Value,
+	descriptorpb.FieldDescriptorProto_TYPE_SINT32:   prototype.Int32Value,
+	descriptorpb.FieldDescriptorProto_TYPE_SFIXED32: prototype.Int32Value,
+	descriptorpb.FieldDescriptorProto_TYPE_UINT32:   prototype.UInt32Value,
+	descriptorpb.FieldDescriptorProto_TYPE_FIXED32:  prototype.UInt32Value,
+	descriptorpb.FieldDescriptorProto_TYPE_BOOL:     prototype.BoolValue,
+	descriptorpb.FieldDescriptorProto_TYPE_STRING:   prototype.StringValue,
+	descriptorpb.FieldDescriptorProto_TYPE_BYTES:    prototype.BytesValue,
+}
+
+// wrapPrimitive wraps the scalar field type with the google.protobuf wrapper message type if the wrapPrimitives option is enabled,
+// and adds the wrappers.proto to the dependency.
+func (c *compiler) wrapPrimitive(field *protobuf.FieldDescriptorProto) *protobuf.FieldDescriptorProto {
+	if !c.opt.wrapPrimitives {
+		return field
+	}
+
+	typeName, ok := wrapperTypes[field.GetType()]
+	if !ok {
+		return field
+	}
+	field.SetType(protobuf.FieldTypeMessage())
+	field.SetTypeName(typeName)
+	c.fdesc.AddDependency(prototype.WrappersProto)
+
+	return field
+}
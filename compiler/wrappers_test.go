@@ -0,0 +1,77 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package compiler
+
+import (
+	"testing"
+
+	"go.lsp.dev/openapi2protobuf/protobuf/prototype"
+)
+
+func TestCompileWrapPrimitives(t *testing.T) {
+	const src = `
+openapi: 3.0.0
+info: {title: test, version: "1"}
+paths:
+  /pets/{petId}:
+    get:
+      parameters:
+        - {name: petId, in: path, required: true, schema: {type: integer, format: int64}}
+      responses:
+        "204": {description: no content}
+components:
+  schemas:
+    Pet:
+      type: object
+      properties:
+        age: {type: integer}
+        name: {type: string}
+        vaccinated: {type: boolean}
+        weight: {type: number}
+`
+
+	result := mustCompileSpec(t, src, WithWrapPrimitives(true))
+	validateDescriptorSet(t, result)
+
+	tests := map[string]map[string]string{ // message name to the field name and its type name
+		"test.Pet": {
+			"age":        "google.protobuf.Int32Value",
+			"name":       "google.protobuf.StringValue",
+			"vaccinated": "google.protobuf.BoolValue",
+			"weight":     "google.protobuf.DoubleValue",
+		},
+		"test.GetPetsByPetIDRequest": {
+			"pet_id": "google.protobuf.Int64Value",
+		},
+	}
+	for msgName, fields := range tests {
+		msg := result.FileDescriptor.FindMessage(msgName)
+		if msg == nil {
+			t.Fatalf("not found %s message", msgName)
+		}
+		for fieldName, typeName := range fields {
+			field := msg.FindFieldByName(fieldName)
+			if field == nil {
+				t.Fatalf("not found %s field in %s", fieldName, msgName)
+			}
+			if field.GetMessageType() == nil {
+				t.Errorf("%s.%s: got %s type but want %s", msgName, fieldName, field.GetType(), typeName)
+				continue
+			}
+			if got := field.GetMessageType().GetFullyQualifiedName(); got != typeName {
+				t.Errorf("%s.%s: got %s type but want %s", msgName, fieldName, got, typeName)
+			}
+		}
+	}
+
+	var found bool
+	for _, dep := range result.FileDescriptorProto.GetDependency() {
+		if dep == prototype.WrappersProto {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("not found %s dependency: %v", prototype.WrappersProto, result.FileDescriptorProto.GetDependency())
+	}
+}
@@ -6,9 +6,14 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"go.lsp.dev/openapi2protobuf/compiler"
 	"go.lsp.dev/openapi2protobuf/internal/conv"
@@ -25,27 +30,197 @@ func init() {
 	)
 }
 
+const usage = `Usage: openapi2protobuf [flags] [<spec>]
+
+openapi2protobuf generates Protocol Buffers v3 and gRPC services definitions from the OpenAPI/Swagger schema.
+
+The OpenAPI spec is read from the -spec flag or the first argument.
+The compiled output is written to stdout unless -out or -out-dir is given.
+
+Flags:
+`
+
+// flags represents a command line flags.
+type flags struct {
+	spec              string
+	packageName       string
+	out               string
+	outDir            string
+	format            string
+	annotation        bool
+	skipRPC           bool
+	skipDeprecatedRPC bool
+	prefixEnums       bool
+	wrapPrimitives    bool
+	formatTypes       map[string]string
+	lockFile          string
+	composeAllOf      bool
+	successResponses  string
+	mediaTypes        string
+	servicePerTag     bool
+}
+
 func main() {
 	if err := run(os.Args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			os.Exit(0)
+		}
 		log.Fatal(err)
 	}
 }
 
+// parseFlags parses the command line args.
+func parseFlags(args []string, output io.Writer) (*flags, error) {
+	f := &flags{
+		formatTypes: make(map[string]string),
+	}
+
+	fs := flag.NewFlagSet("openapi2protobuf", flag.ContinueOnError)
+	fs.SetOutput(output)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+
+	fs.StringVar(&f.spec, "spec", "", "path to the OpenAPI spec file")
+	fs.StringVar(&f.packageName, "package", "", "package name of the compiled Protocol Buffers (required)")
+	fs.StringVar(&f.out, "out", "", "path to the output .proto file")
+	fs.StringVar(&f.outDir, "out-dir", "", "path to the output directory. the file name is derived from the package name")
+	fs.StringVar(&f.format, "format", string(compiler.FormatProto), "output format. one of proto, descriptor_set or json")
+	fs.BoolVar(&f.annotation, "annotation", false, `add "google.api.http" annotations to the RPC methods`)
+	fs.BoolVar(&f.skipRPC, "skip-rpc", false, "skip generating services and RPCs, generates messages only")
+	fs.BoolVar(&f.servicePerTag, "service-per-tag", false, "generate the service of each operation tag, instead of the single service")
+	fs.BoolVar(&f.skipDeprecatedRPC, "skip-deprecated-rpc", false, "skip generating RPCs for operations marked as deprecated")
+	fs.BoolVar(&f.prefixEnums, "prefix-enums", true, "prefix enum values with their enum name")
+	fs.BoolVar(&f.wrapPrimitives, "wrap-primitives", false, "wrap primitive types with the google.protobuf wrapper message types")
+	fs.BoolVar(&f.composeAllOf, "compose-allof", false, "compile allOf to the message which has the field of each member, instead of merging all members")
+	fs.StringVar(&f.successResponses, "success-responses", strings.Join(compiler.DefaultSuccessResponses, ","), "comma-separated rule to select the success response of the operation. each is lowest, 2XX, default or the 2xx status code")
+	fs.StringVar(&f.mediaTypes, "media-types", strings.Join(compiler.DefaultMediaTypes, ","), "comma-separated media type preference of the request and response content. the wildcard such as application/*+json is allowed")
+	fs.Func("format-type", "map the OpenAPI format to the Protocol Buffers type as `format=type`. can be repeated", func(s string) error {
+		format, typ, ok := strings.Cut(s, "=")
+		if !ok || format == "" || typ == "" {
+			return fmt.Errorf("invalid format type %q, must be format=type", s)
+		}
+		f.formatTypes[format] = typ
+		return nil
+	})
+	fs.StringVar(&f.lockFile, "lock-file", "", "path to the field number lock file. keeps the field numbers stable across regenerations, and is updated after compile")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if f.spec == "" {
+		f.spec = fs.Arg(0)
+	}
+
+	switch {
+	case f.spec == "":
+		fs.Usage()
+		return nil, errors.New("missing OpenAPI spec file")
+	case f.packageName == "":
+		fs.Usage()
+		return nil, errors.New("missing -package flag")
+	case f.out != "" && f.outDir != "":
+		return nil, errors.New("-out and -out-dir flags are mutually exclusive")
+	}
+
+	switch compiler.Format(f.format) {
+	case compiler.FormatProto, compiler.FormatDescriptorSet, compiler.FormatJSON:
+		// nothing to do
+	default:
+		return nil, fmt.Errorf("unknown -format flag value: %q", f.format)
+	}
+
+	return f, nil
+}
+
 func run(args []string) error {
-	f := args[0]
-	pkgname := args[1]
+	f, err := parseFlags(args, os.Stderr)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	schema, err := openapi.LoadFile(ctx, f)
+	schema, err := openapi.LoadFile(ctx, f.spec)
 	if err != nil {
-		return fmt.Errorf("could not load %s OpenAPI file: %w", f, err)
+		return fmt.Errorf("could not load %s OpenAPI file: %w", f.spec, err)
 	}
 
-	if _, err = compiler.Compile(ctx, schema, compiler.WithPackageName(pkgname)); err != nil {
+	opts := []compiler.Option{
+		compiler.WithPackageName(f.packageName),
+		compiler.WithAnnotation(f.annotation),
+		compiler.WithSkipRPC(f.skipRPC),
+		compiler.WithSkipDeprecatedRPC(f.skipDeprecatedRPC),
+		compiler.WithPrefixEnums(f.prefixEnums),
+		compiler.WithWrapPrimitives(f.wrapPrimitives),
+		compiler.WithFormatTypes(f.formatTypes),
+		compiler.WithComposeAllOf(f.composeAllOf),
+		compiler.WithSuccessResponses(strings.Split(f.successResponses, ",")),
+		compiler.WithMediaTypes(strings.Split(f.mediaTypes, ",")),
+		compiler.WithServicePerTag(f.servicePerTag),
+	}
+	if f.lockFile != "" {
+		lock, err := compiler.LoadFieldNumberLock(f.lockFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, compiler.WithFieldNumberLock(lock))
+	}
+	result, err := compiler.Compile(ctx, schema, opts...)
+	if err != nil {
+		var diags compiler.Diagnostics
+		if errors.As(err, &diags) {
+			printDiagnostics(os.Stderr, f.spec, diags)
+			return fmt.Errorf("could not compile file descriptor: %d errors", diags.ErrorCount())
+		}
 		return fmt.Errorf("could not compile file descriptor: %w", err)
 	}
+	printDiagnostics(os.Stderr, f.spec, result.Diagnostics)
+
+	if f.lockFile != "" {
+		b, err := result.FieldNumberLock.Marshal()
+		if err != nil {
+			return err
+		}
+		if err := os.WriteFile(f.lockFile, b, 0o644); err != nil {
+			return fmt.Errorf("could not write %s: %w", f.lockFile, err)
+		}
+	}
+
+	format := compiler.Format(f.format)
+	b, err := result.Marshal(format)
+	if err != nil {
+		return err
+	}
+
+	out := f.out
+	if f.outDir != "" {
+		out = filepath.Join(f.outDir, strings.TrimSuffix(result.FileDescriptorProto.GetName(), ".proto")+format.Ext())
+	}
+
+	if out == "" {
+		_, err := os.Stdout.Write(b)
+		return err
+	}
+
+	if dir := filepath.Dir(out); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("could not create %s directory: %w", dir, err)
+		}
+	}
+	if err := os.WriteFile(out, b, 0o644); err != nil {
+		return fmt.Errorf("could not write %s: %w", out, err)
+	}
 
 	return nil
 }
+
+// printDiagnostics prints the diagnostics in the "file:line:col: severity: message" format to w.
+func printDiagnostics(w io.Writer, filename string, diags compiler.Diagnostics) {
+	for _, d := range diags {
+		fmt.Fprintln(w, d.Format(filename))
+	}
+}
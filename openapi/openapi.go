@@ -6,18 +6,28 @@ package openapi
 import (
 	"context"
 	"fmt"
+	"os"
+	"strconv"
+	"strings"
 
 	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
 )
 
 // Schema represents a root of an OpenAPI v3 document.
 type Schema struct {
 	*openapi3.T
+
+	// node is the root node of the loaded document to locate the JSON pointer.
+	node *yaml.Node
 }
 
 // RootOption represents a Protocol Buffers root options.
 type RootOption openapi3.ExtensionProps
 
+// ExtensionKey is the key name of the Extension.
+const ExtensionKey = "x-extension"
+
 // Extension represents a Protocol Buffers extension from within OpenAPI spec.
 //
 // The key name must be "x-extension".
@@ -46,7 +56,62 @@ func LoadFile(ctx context.Context, f string) (*Schema, error) {
 
 	schema.InternalizeRefs(ctx, openapi3.DefaultRefNameResolver)
 
-	return &Schema{T: schema}, nil
+	s := &Schema{T: schema}
+	// the JSON document is also the YAML document
+	if b, err := os.ReadFile(f); err == nil {
+		var node yaml.Node
+		if err := yaml.Unmarshal(b, &node); err == nil && len(node.Content) > 0 {
+			s.node = node.Content[0]
+		}
+	}
+
+	return s, nil
+}
+
+// pointerUnescaper unescapes the JSON pointer token by RFC 6901.
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// Position returns the 1-based line and column of the JSON pointer in the loaded document, such as "#/components/schemas/Pet".
+//
+// The position of the mapping key is returned if the pointer refers to the mapping value.
+// Position returns false if the pointer is not found, or s is not loaded by LoadFile.
+func (s *Schema) Position(pointer string) (line, column int, ok bool) {
+	if s.node == nil || !strings.HasPrefix(pointer, "#") {
+		return 0, 0, false
+	}
+
+	node, pos := s.node, s.node
+	for _, tok := range strings.Split(strings.TrimPrefix(pointer, "#"), "/")[1:] {
+		tok = pointerUnescaper.Replace(tok)
+
+		switch node.Kind {
+		case yaml.MappingNode:
+			found := false
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == tok {
+					node, pos = node.Content[i+1], node.Content[i]
+					found = true
+					break
+				}
+			}
+			if !found {
+				return 0, 0, false
+			}
+
+		case yaml.SequenceNode:
+			i, err := strconv.Atoi(tok)
+			if err != nil || i < 0 || i >= len(node.Content) {
+				return 0, 0, false
+			}
+			node = node.Content[i]
+			pos = node
+
+		default:
+			return 0, 0, false
+		}
+	}
+
+	return pos.Line, pos.Column, true
 }
 
 // The following tokens are OpenAPI Specification Header Object field names.
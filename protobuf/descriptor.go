@@ -29,7 +29,7 @@ type FileDescriptorProto struct {
 func NewFileDescriptorProto(fqn string) *FileDescriptorProto {
 	return &FileDescriptorProto{
 		desc: &descriptorpb.FileDescriptorProto{
-			Name:    proto.String(strcase.ToSnake(splitByLastDot(fqn))),
+			Name:    proto.String(fileName(fqn)),
 			Package: proto.String(packageName(fqn)),
 			Options: &descriptorpb.FileOptions{
 				GoPackage: proto.String(goPackage(fqn)),
@@ -70,6 +70,15 @@ func splitByLastDot(fqn string) string {
 	return fqn[idx+1:]
 }
 
+// fileName returns the file name without the extension of the fqn package, such as "foo_v1" of "foo.v1".
+func fileName(fqn string) string {
+	if fqn == "" {
+		return ""
+	}
+
+	return strings.ToLower(strings.ReplaceAll(packageName(fqn), ".", "_")) + ".proto"
+}
+
 func packageName(fqn string) string {
 	return strings.ReplaceAll(fqn, "/", ".")
 }
@@ -112,8 +121,11 @@ func objcClassPrefix(fqn string) string {
 	return b.String()
 }
 
+// SetPackage sets the package of the file to fqn, together with the file name and go_package derived from it.
 func (fd *FileDescriptorProto) SetPackage(fqn string) {
-	fd.desc.Package = proto.String(fqn)
+	fd.desc.Name = proto.String(fileName(fqn))
+	fd.desc.Package = proto.String(packageName(fqn))
+	fd.desc.GetOptions().GoPackage = proto.String(goPackage(fqn))
 }
 
 func (fd *FileDescriptorProto) AddPackageLeadingComments(comments string) {
@@ -215,16 +227,21 @@ func (fd *FileDescriptorProto) AddEnum(enum *EnumDescriptorProto) *FileDescripto
 }
 
 func (fd *FileDescriptorProto) AddService(service *ServiceDescriptorProto) *FileDescriptorProto {
+	index := int32(len(fd.desc.Service))
 	comments := service.GetComment()
 	if comments != nil {
 		loc := &descriptorpb.SourceCodeInfo_Location{
 			LeadingComments:         proto.String(comments.LeadingComments),
 			TrailingComments:        proto.String(comments.TrailingComments),
 			LeadingDetachedComments: comments.LeadingDetachedComments,
-			Path:                    []int32{prototag.FileServices, int32(len(fd.services)) - 1},
+			Path:                    []int32{prototag.FileServices, index},
 		}
 		fd.desc.SourceCodeInfo.Location = append(fd.desc.SourceCodeInfo.Location, loc)
 	}
+	for _, loc := range service.GetMethodLocations() {
+		loc.Path = append([]int32{prototag.FileServices, index}, loc.Path...)
+		fd.desc.SourceCodeInfo.Location = append(fd.desc.SourceCodeInfo.Location, loc)
+	}
 
 	fd.services[service.GetName()] = true
 	fd.desc.Service = append(fd.desc.Service, service.Build())
@@ -44,6 +44,22 @@ func (fid *FieldDescriptorProto) SetNumber() *FieldDescriptorProto {
 	return fid
 }
 
+func (fid *FieldDescriptorProto) SetFieldNumber(number int32) *FieldDescriptorProto {
+	fid.desc.Number = proto.Int32(number)
+
+	return fid
+}
+
+func (fid *FieldDescriptorProto) GetType() descriptorpb.FieldDescriptorProto_Type {
+	return fid.desc.GetType()
+}
+
+func (fid *FieldDescriptorProto) SetType(fieldType *descriptorpb.FieldDescriptorProto_Type) *FieldDescriptorProto {
+	fid.desc.Type = fieldType
+
+	return fid
+}
+
 func (fid *FieldDescriptorProto) GetTypeName() *string {
 	return fid.desc.TypeName
 }
@@ -54,6 +70,12 @@ func (fid *FieldDescriptorProto) SetTypeName(name string) *FieldDescriptorProto
 	return fid
 }
 
+func (fid *FieldDescriptorProto) ClearTypeName() *FieldDescriptorProto {
+	fid.desc.TypeName = nil
+
+	return fid
+}
+
 func (fid *FieldDescriptorProto) SetOneofIndex(idx int32) *FieldDescriptorProto {
 	fid.desc.OneofIndex = proto.Int32(idx)
 
@@ -66,6 +88,18 @@ func (fid *FieldDescriptorProto) SetRepeated() *FieldDescriptorProto {
 	return fid
 }
 
+func (fid *FieldDescriptorProto) SetOptional() *FieldDescriptorProto {
+	fid.desc.Label = descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum()
+
+	return fid
+}
+
+func (fid *FieldDescriptorProto) SetExtendee(extendee string) *FieldDescriptorProto {
+	fid.desc.Extendee = proto.String(extendee)
+
+	return fid
+}
+
 func (fid *FieldDescriptorProto) AddLeadingComment(fn, leading string) *FieldDescriptorProto {
 	fid.comment.LeadingComments = conv.NormalizeComment(fn, leading)
 
@@ -99,6 +133,10 @@ func (fid *FieldDescriptorProto) SetJsonName(jsonName string) *FieldDescriptorPr
 	return fid
 }
 
+func (fid *FieldDescriptorProto) GetFieldOption() *descriptorpb.FieldOptions {
+	return fid.desc.Options
+}
+
 func (fid *FieldDescriptorProto) SetFieldOption(fieldOptions *descriptorpb.FieldOptions) *FieldDescriptorProto {
 	fid.desc.Options = fieldOptions
 
@@ -4,6 +4,8 @@
 package protobuf
 
 import (
+	"sort"
+
 	"google.golang.org/protobuf/proto"
 	"google.golang.org/protobuf/types/descriptorpb"
 
@@ -93,6 +95,19 @@ func (md *MessageDescriptorProto) AddField(field *FieldDescriptorProto) *Message
 	return md
 }
 
+func (md *MessageDescriptorProto) AddProto3OptionalField(field *FieldDescriptorProto) *MessageDescriptorProto {
+	if md.field[field.GetName()] {
+		return md
+	}
+
+	// add synthetic oneof for the proto3 optional field
+	md.AddOneof(NewOneofDescriptorProto("_" + field.GetName()))
+	field.SetOneofIndex(md.GetOneofIndex())
+	field.SetProto3Optional()
+
+	return md.AddField(field)
+}
+
 func (md *MessageDescriptorProto) GetFieldOrder() []string {
 	return md.fieldOrder
 }
@@ -108,30 +123,41 @@ func (md *MessageDescriptorProto) GetFieldLocations() []*descriptorpb.SourceCode
 	return append(fieldLocations, md.enumLocations...)
 }
 
+// SortField sorts the fields by the order of the field names, and renumbers them.
+//
+// The fields not in the order are placed after the ordered fields in the current order.
 func (md *MessageDescriptorProto) SortField(order []string) *MessageDescriptorProto {
-	propOrder := make([]string, len(order))
-	for i, s := range order {
-		propOrder[i] = s
+	rank := make(map[string]int, len(order))
+	for i, name := range order {
+		if _, ok := rank[name]; !ok {
+			rank[name] = i
+		}
 	}
 
-	n := len(md.desc.Field)
-	fdescFields := make([]*descriptorpb.FieldDescriptorProto, n)
-	copy(fdescFields, md.desc.Field)
-	md.desc.Field = md.desc.Field[0:] // reset
-
-	i := 0
-	for _, field := range propOrder {
-		for _, msgfield := range fdescFields {
-			if msgfield.GetName() == field {
-				md.fieldLocations[msgfield.GetNumber()-1].Path[1] = int32(i)
-
-				msgfield.Number = proto.Int32(int32(i + 1))
-				md.desc.Field[i] = msgfield
-				i++
-				break
-			}
+	fields := make([]*descriptorpb.FieldDescriptorProto, len(md.desc.Field))
+	copy(fields, md.desc.Field)
+	sort.SliceStable(fields, func(i, j int) bool {
+		ri, iok := rank[fields[i].GetName()]
+		rj, jok := rank[fields[j].GetName()]
+		if iok && jok {
+			return ri < rj
+		}
+		return iok && !jok
+	})
+
+	fieldOrder := make([]string, len(fields))
+	fieldLocations := make(map[int32]*descriptorpb.SourceCodeInfo_Location, len(md.fieldLocations))
+	for i, field := range fields {
+		if loc, ok := md.fieldLocations[field.GetNumber()-1]; ok {
+			loc.Path[1] = int32(i)
+			fieldLocations[int32(i)] = loc
 		}
+		field.Number = proto.Int32(int32(i + 1))
+		fieldOrder[i] = field.GetName()
 	}
+	md.desc.Field = fields
+	md.fieldOrder = fieldOrder
+	md.fieldLocations = fieldLocations
 
 	return md
 }
@@ -164,6 +190,23 @@ func (md *MessageDescriptorProto) GetFieldType() *descriptorpb.FieldDescriptorPr
 	return descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum()
 }
 
+func (md *MessageDescriptorProto) GetFieldTypeName() string {
+	if len(md.desc.Field) == 1 {
+		return md.desc.Field[0].GetTypeName()
+	}
+
+	return ""
+}
+
+// GetEnumTypeName returns the name of the enum if md only has the single enum, such as the message compiled from the enum schema.
+func (md *MessageDescriptorProto) GetEnumTypeName() string {
+	if len(md.desc.Field) == 0 && len(md.desc.EnumType) == 1 {
+		return md.desc.EnumType[0].GetName()
+	}
+
+	return ""
+}
+
 func (md *MessageDescriptorProto) IsEmptyField() bool {
 	return len(md.desc.Field) == 0 && len(md.desc.EnumType) == 0 && len(md.desc.NestedType) == 0
 }
@@ -24,6 +24,12 @@ func (md *OneofDescriptorProto) GetName() string {
 	return md.desc.GetName()
 }
 
+func (md *OneofDescriptorProto) SetName(name string) *OneofDescriptorProto {
+	md.desc.Name = proto.String(name)
+
+	return md
+}
+
 func (md *OneofDescriptorProto) Build() *descriptorpb.OneofDescriptorProto {
 	return md.desc
 }
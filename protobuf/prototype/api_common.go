@@ -25,6 +25,7 @@ import (
 )
 
 const (
+	HttpExtension               = "google.api.http"
 	Authentication              = "google.api.Authentication"
 	AuthenticationRule          = "google.api.AuthenticationRule"
 	AuthProvider                = "google.api.AuthProvider"
@@ -75,6 +76,7 @@ const (
 )
 
 const (
+	AnnotationsProto       = "google/api/annotations.proto"
 	AuthProto              = "google/api/auth.proto"
 	BackendProto           = "google/api/backend.proto"
 	BillingProto           = "google/api/billing.proto"
@@ -106,6 +108,7 @@ const (
 )
 
 var KnownCommonImports = map[string]string{
+	HttpExtension:               AnnotationsProto,
 	Authentication:              AuthProto,
 	AuthenticationRule:          AuthProto,
 	AuthProvider:                AuthProto,
@@ -155,6 +158,10 @@ var KnownCommonImports = map[string]string{
 	ResourceReference:           ResourceProto,
 }
 
+func AnnotationsDescriptor() *descriptorpb.FileDescriptorProto {
+	return protodesc.ToFileDescriptorProto(annotations.File_google_api_annotations_proto)
+}
+
 func AuthDescriptor() *descriptorpb.FileDescriptorProto {
 	return protodesc.ToFileDescriptorProto(serviceconfig.File_google_api_auth_proto)
 }
@@ -252,6 +259,7 @@ func ResourceDescriptorDescriptor() *descriptorpb.FileDescriptorProto {
 // }
 
 var KnownCommonDescriptor = map[string]*descriptorpb.FileDescriptorProto{
+	AnnotationsProto:       AnnotationsDescriptor(),
 	AuthProto:              AuthDescriptor(),
 	BackendProto:           BackendDescriptor(),
 	BillingProto:           BillingDescriptor(),
@@ -27,6 +27,7 @@ const (
 	NullValue     = "google.protobuf.NullValue"
 	Struct        = "google.protobuf.Struct"
 	Timestamp     = "google.protobuf.Timestamp"
+	Value         = "google.protobuf.Value"
 	DoubleValue   = "google.protobuf.DoubleValue"
 	FloatValue    = "google.protobuf.FloatValue"
 	Int64Value    = "google.protobuf.Int64Value"
@@ -38,6 +39,16 @@ const (
 	BytesValue    = "google.protobuf.BytesValue"
 )
 
+const (
+	FileOptions      = "google.protobuf.FileOptions"
+	MessageOptions   = "google.protobuf.MessageOptions"
+	FieldOptions     = "google.protobuf.FieldOptions"
+	OneofOptions     = "google.protobuf.OneofOptions"
+	EnumOptions      = "google.protobuf.EnumOptions"
+	EnumValueOptions = "google.protobuf.EnumValueOptions"
+	ServiceOptions   = "google.protobuf.ServiceOptions"
+)
+
 const (
 	AnyProto        = "google/protobuf/any.proto"
 	DurationProto   = "google/protobuf/duration.proto"
@@ -56,6 +67,7 @@ var Imports = map[string]string{
 	MethodOptions: DescriptorProto,
 	NullValue:     StructProto,
 	Struct:        StructProto,
+	Value:         StructProto,
 	Timestamp:     TimestampProto,
 	DoubleValue:   WrappersProto,
 	FloatValue:    WrappersProto,
@@ -66,6 +78,14 @@ var Imports = map[string]string{
 	BoolValue:     WrappersProto,
 	StringValue:   WrappersProto,
 	BytesValue:    WrappersProto,
+
+	FileOptions:      DescriptorProto,
+	MessageOptions:   DescriptorProto,
+	FieldOptions:     DescriptorProto,
+	OneofOptions:     DescriptorProto,
+	EnumOptions:      DescriptorProto,
+	EnumValueOptions: DescriptorProto,
+	ServiceOptions:   DescriptorProto,
 }
 
 func AnyDescriptor() *descriptorpb.FileDescriptorProto {
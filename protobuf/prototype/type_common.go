@@ -0,0 +1,77 @@
+// Copyright 2022 The Go Language Server Authors
+// SPDX-License-Identifier: BSD-3-Clause
+
+package prototype
+
+import (
+	"google.golang.org/genproto/googleapis/type/date"
+	"google.golang.org/genproto/googleapis/type/datetime"
+	"google.golang.org/genproto/googleapis/type/decimal"
+	"google.golang.org/genproto/googleapis/type/interval"
+	"google.golang.org/genproto/googleapis/type/money"
+	"google.golang.org/genproto/googleapis/type/timeofday"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const (
+	Date      = "google.type.Date"
+	DateTime  = "google.type.DateTime"
+	TimeZone  = "google.type.TimeZone"
+	Decimal   = "google.type.Decimal"
+	Interval  = "google.type.Interval"
+	Money     = "google.type.Money"
+	TimeOfDay = "google.type.TimeOfDay"
+)
+
+const (
+	DateProto      = "google/type/date.proto"
+	DateTimeProto  = "google/type/datetime.proto"
+	DecimalProto   = "google/type/decimal.proto"
+	IntervalProto  = "google/type/interval.proto"
+	MoneyProto     = "google/type/money.proto"
+	TimeOfDayProto = "google/type/timeofday.proto"
+)
+
+var KnownTypeImports = map[string]string{
+	Date:      DateProto,
+	DateTime:  DateTimeProto,
+	TimeZone:  DateTimeProto,
+	Decimal:   DecimalProto,
+	Interval:  IntervalProto,
+	Money:     MoneyProto,
+	TimeOfDay: TimeOfDayProto,
+}
+
+func DateDescriptor() *descriptorpb.FileDescriptorProto {
+	return protodesc.ToFileDescriptorProto(date.File_google_type_date_proto)
+}
+
+func DateTimeDescriptor() *descriptorpb.FileDescriptorProto {
+	return protodesc.ToFileDescriptorProto(datetime.File_google_type_datetime_proto)
+}
+
+func DecimalDescriptor() *descriptorpb.FileDescriptorProto {
+	return protodesc.ToFileDescriptorProto(decimal.File_google_type_decimal_proto)
+}
+
+func IntervalDescriptor() *descriptorpb.FileDescriptorProto {
+	return protodesc.ToFileDescriptorProto(interval.File_google_type_interval_proto)
+}
+
+func MoneyDescriptor() *descriptorpb.FileDescriptorProto {
+	return protodesc.ToFileDescriptorProto(money.File_google_type_money_proto)
+}
+
+func TimeOfDayDescriptor() *descriptorpb.FileDescriptorProto {
+	return protodesc.ToFileDescriptorProto(timeofday.File_google_type_timeofday_proto)
+}
+
+var KnownTypeDescriptor = map[string]*descriptorpb.FileDescriptorProto{
+	DateProto:      DateDescriptor(),
+	DateTimeProto:  DateTimeDescriptor(),
+	DecimalProto:   DecimalDescriptor(),
+	IntervalProto:  IntervalDescriptor(),
+	MoneyProto:     MoneyDescriptor(),
+	TimeOfDayProto: TimeOfDayDescriptor(),
+}
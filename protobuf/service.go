@@ -48,7 +48,7 @@ func (sd *ServiceDescriptorProto) AddMethod(method *MethodDescriptorProto) *Serv
 			LeadingComments:         proto.String(comments.LeadingComments),
 			TrailingComments:        proto.String(comments.TrailingComments),
 			LeadingDetachedComments: comments.LeadingDetachedComments,
-			Path:                    []int32{prototag.MessageFields, sd.numMethod - 1},
+			Path:                    []int32{prototag.ServiceMethods, sd.numMethod - 1},
 		}
 		sd.methodLocations[sd.numMethod-1] = loc
 	}
@@ -57,6 +57,18 @@ func (sd *ServiceDescriptorProto) AddMethod(method *MethodDescriptorProto) *Serv
 	return sd
 }
 
+// GetMethodLocations returns the source code locations of the method comments, which are relative to the service.
+func (sd *ServiceDescriptorProto) GetMethodLocations() []*descriptorpb.SourceCodeInfo_Location {
+	methodLocations := make([]*descriptorpb.SourceCodeInfo_Location, 0, len(sd.methodLocations))
+	for i := int32(0); i < sd.numMethod; i++ {
+		if loc, ok := sd.methodLocations[i]; ok {
+			methodLocations = append(methodLocations, loc)
+		}
+	}
+
+	return methodLocations
+}
+
 func (sd *ServiceDescriptorProto) SetServiceOptions(options *descriptorpb.ServiceOptions) *ServiceDescriptorProto {
 	sd.desc.Options = options
 	return sd
@@ -119,6 +131,13 @@ func (sd *MethodDescriptorProto) AddLeadingComment(fn, leading string) *MethodDe
 	return sd
 }
 
+// SetLeadingComment sets the leading comment as is, unlike AddLeadingComment which normalizes it to the godoc style sentence.
+func (sd *MethodDescriptorProto) SetLeadingComment(leading string) *MethodDescriptorProto {
+	sd.comment.LeadingComments = leading
+
+	return sd
+}
+
 func (sd *MethodDescriptorProto) AddTrailingComment(trailing string) *MethodDescriptorProto {
 	sd.comment.TrailingComments = trailing
 